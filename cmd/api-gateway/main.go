@@ -3,28 +3,40 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"net/http/pprof"
 	"os"
 	"os/signal"
+	"reflect"
 	"strconv"
 	"syscall"
+	"time"
 
+	"github.com/akshaysangma/go-serve/internal/api-gateway/auth"
 	"github.com/akshaysangma/go-serve/internal/api-gateway/handlers"
 	"github.com/akshaysangma/go-serve/internal/api-gateway/middleware"
 	"github.com/akshaysangma/go-serve/internal/api-gateway/repositories"
 	"github.com/akshaysangma/go-serve/internal/api-gateway/services"
 	"github.com/akshaysangma/go-serve/internal/common/config"
+	"github.com/akshaysangma/go-serve/internal/common/health"
+	"github.com/akshaysangma/go-serve/internal/common/jwtkeys"
 	"github.com/akshaysangma/go-serve/internal/common/logging"
+	"github.com/akshaysangma/go-serve/internal/common/metrics"
+	"github.com/akshaysangma/go-serve/internal/common/tracing"
 	database "github.com/akshaysangma/go-serve/internal/database/postgres"
 	db "github.com/akshaysangma/go-serve/internal/database/postgres/sqlc"
+	redisdb "github.com/akshaysangma/go-serve/internal/database/redis"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 
 	_ "github.com/lib/pq"
 )
 
 func main() {
-	config := config.LoadConfig()
-	logger, err := logging.InitLogger(config.Log.Level, config.Log.Encoding)
+	cfg := config.LoadConfig()
+	logger, logLevel, err := logging.InitLogger(cfg.Log.Level, cfg.Log.Encoding)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
 		os.Exit(1)
@@ -32,20 +44,99 @@ func main() {
 	// flush all buffer before exiting
 	defer logger.Sync()
 
-	logger.Info("Configuration loaded successfully", zap.Int("port", config.App.Port), zap.String("log_Level", config.Log.Level))
+	logger.Info("Configuration loaded successfully", zap.Int("port", cfg.App.Port), zap.String("log_Level", cfg.Log.Level))
+	logger.Debug("Effective configuration", zap.Any("config", cfg.Redacted()))
+
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing)
+	if err != nil {
+		logger.Fatal("Unable to initialize tracing", zap.Error(err))
+	}
 
 	// creating Db Connection Pool
-	dB, err := database.ConnectDB(config.Database.URL, config.Database.MaxConnections)
+	dB, err := database.ConnectDB(cfg.Database.URL, cfg.Database.MaxConnections)
 	if err != nil {
 		logger.Fatal("Unable to connect to Database", zap.Error(err))
 	}
 	defer dB.Close()
 	logger.Info("Successfully connected to Database")
 
+	if err := metrics.RegisterPool(dB); err != nil {
+		logger.Fatal("Unable to register database pool metrics", zap.Error(err))
+	}
+
 	dBQueries := db.New(dB)
 
+	var redisClient *redis.Client
+	if cfg.RateLimit.Backend == middleware.RateLimitBackendRedis {
+		redisClient, err = redisdb.NewClient(cfg.Redis.URL)
+		if redisClient == nil {
+			logger.Fatal("Unable to create Redis client", zap.Error(err))
+		}
+		defer redisClient.Close()
+		if err != nil {
+			logger.Warn("Redis unavailable, rate limits fall back to memory until it recovers", zap.Error(err))
+		} else {
+			logger.Info("Successfully connected to Redis")
+		}
+	}
+
+	rateLimits, err := middleware.NewRateLimitPolicies(cfg.RateLimit, redisClient, logger)
+	if err != nil {
+		logger.Fatal("Unable to create rate limit policies", zap.Error(err))
+	}
+
+	keyring, err := jwtkeys.NewKeyring(cfg.JWT)
+	if err != nil {
+		logger.Fatal("Unable to load JWT keys", zap.Error(err))
+	}
+
+	configWatcher := config.NewWatcher(cfg, logger)
+	configWatcher.Subscribe(func(old, new *config.Config) error {
+		if new.Log.Level == old.Log.Level {
+			// Keep a level set through /admin/loglevel.
+			return nil
+		}
+		level, err := zapcore.ParseLevel(new.Log.Level)
+		if err != nil {
+			return fmt.Errorf("log level: %w", err)
+		}
+		logLevel.SetLevel(level)
+		logger.Info("Log level reloaded", zap.String("level", level.String()))
+		return nil
+	})
+	configWatcher.Subscribe(func(old, new *config.Config) error {
+		if reflect.DeepEqual(new.RateLimit, old.RateLimit) {
+			return nil
+		}
+		if err := rateLimits.Reload(new.RateLimit); err != nil {
+			return fmt.Errorf("rate limit policies: %w", err)
+		}
+		logger.Info("Rate limit policies reloaded")
+		return nil
+	})
+	configWatcher.Subscribe(func(_, new *config.Config) error {
+		// Key files may have changed even if the config did not.
+		if err := keyring.Reload(new.JWT); err != nil {
+			return fmt.Errorf("JWT keyring: %w", err)
+		}
+		logger.Info("JWT keyring reloaded")
+		return nil
+	})
+
+	healthChecker := health.NewChecker(cfg.Health.CheckTimeout, cfg.Health.CacheTTL)
+	healthChecker.Register("postgres", dB.Ping)
+	if redisClient != nil {
+		healthChecker.Register("redis", func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		})
+	}
+
 	router := http.NewServeMux()
-	router.Handle("GET /health", handlers.Healthcheck(logger))
+	metricsMiddleware := middleware.MetricsMiddleware()
+	router.Handle("GET /health", metricsMiddleware(handlers.LivenessHandler(logger)))
+	router.Handle("GET /livez", metricsMiddleware(handlers.LivenessHandler(logger)))
+	router.Handle("GET /readyz", metricsMiddleware(handlers.ReadinessHandler(healthChecker, false, logger)))
+	router.Handle("GET /.well-known/jwks.json", metricsMiddleware(handlers.JWKSHandler(keyring, logger)))
 
 	// V1 API Group
 	v1 := http.NewServeMux()
@@ -55,38 +146,129 @@ func main() {
 	userRepo := repositories.NewUserRepository(dBQueries)
 	articleRepo := repositories.NewArticleRepository(dBQueries)
 	userService := services.NewUserService(userRepo, articleRepo, dB, logger)
-	v1.Handle("GET /login", middleware.RequestLoggerMiddleware(logger)(handlers.LoginHandler(config.JWT, userService, logger)))
-	userMiddlewareChain := middleware.ChainMiddleware(middleware.RequestLoggerMiddleware(logger), middleware.RateLimitMiddleware(config.RateLimit, logger), middleware.AuthMiddleware([]byte(config.JWT.Secret), logger))
-	v1.Handle("GET /users/{id}", userMiddlewareChain(handlers.GetUserByIDHandler(userService, logger)))
-	v1.Handle("POST /users", userMiddlewareChain(handlers.CreateUserHandler(userService, logger)))
-	v1.Handle("GET /users", userMiddlewareChain(handlers.ListUsersHandler(userService, logger)))
+	refreshTokenRepo := repositories.NewRefreshTokenRepository(dBQueries)
+	refreshTokenService := services.NewRefreshTokenService(refreshTokenRepo, dB, cfg.JWT.RefreshExpirationDuration, logger)
+	revokedTokenRepo := repositories.NewRevokedTokenRepository(dBQueries)
+	tokenRevocationService := services.NewTokenRevocationService(revokedTokenRepo, logger)
+	apiKeyRepo := repositories.NewAPIKeyRepository(dBQueries)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, userRepo, logger)
+	cleanupCtx, stopCleanup := context.WithCancel(context.Background())
+	defer stopCleanup()
+	go tokenRevocationService.RunCleanup(cleanupCtx, time.Hour)
+	authMiddlewareChain := middleware.ChainMiddleware(middleware.MetricsMiddleware(), middleware.TracingMiddleware(), middleware.RequestLoggerMiddleware(logger), middleware.RateLimitMiddleware(rateLimits, "auth", logger))
+	v1.Handle("POST /auth/register", authMiddlewareChain(handlers.CreateUserHandler(userService, logger)))
+	v1.Handle("POST /auth/login", authMiddlewareChain(handlers.LoginHandler(cfg.JWT, keyring, userService, refreshTokenService, logger)))
+	v1.Handle("POST /auth/refresh", authMiddlewareChain(handlers.RefreshHandler(cfg.JWT, keyring, userService, refreshTokenService, logger)))
+	userMiddlewareChain := middleware.ChainMiddleware(middleware.MetricsMiddleware(), middleware.TracingMiddleware(), middleware.RequestLoggerMiddleware(logger), middleware.ClientIPRateLimitMiddleware(rateLimits, middleware.ClientIPRateLimitPolicy, logger), middleware.AuthMiddleware(keyring, tokenRevocationService, apiKeyService, logger), middleware.RateLimitMiddleware(rateLimits, middleware.DefaultRateLimitPolicy, logger))
+	// Every route requires a scope, which limits API keys to the routes
+	// they were granted; access tokens pass the scope check.
+	scopedChain := func(scope string) middleware.Middleware {
+		return middleware.ChainMiddleware(userMiddlewareChain, middleware.RequireScope(logger, scope))
+	}
+	v1.Handle("POST /auth/logout", userMiddlewareChain(handlers.LogoutHandler(tokenRevocationService, refreshTokenService, logger)))
+	v1.Handle("POST /api-keys", scopedChain(auth.ScopeAPIKeysWrite)(handlers.CreateAPIKeyHandler(apiKeyService, logger)))
+	v1.Handle("GET /api-keys", scopedChain(auth.ScopeAPIKeysRead)(handlers.ListAPIKeysHandler(apiKeyService, logger)))
+	v1.Handle("DELETE /api-keys/{id}", scopedChain(auth.ScopeAPIKeysWrite)(handlers.RevokeAPIKeyHandler(apiKeyService, logger)))
+	v1.Handle("GET /users/{id}", scopedChain(auth.ScopeUsersRead)(handlers.GetUserByIDHandler(userService, logger)))
+	v1.Handle("POST /users", scopedChain(auth.ScopeUsersWrite)(handlers.CreateUserHandler(userService, logger)))
+	adminMiddlewareChain := middleware.ChainMiddleware(userMiddlewareChain, middleware.RequireRole(logger, auth.RoleAdmin), middleware.RequireScope(logger, auth.ScopeAdmin))
+	v1.Handle("GET /users", adminMiddlewareChain(handlers.ListUsersHandler(userService, logger)))
+	v1.Handle("PUT /users/{id}/roles/{role}", adminMiddlewareChain(handlers.AssignUserRoleHandler(userService, logger)))
+	v1.Handle("DELETE /users/{id}/roles/{role}", adminMiddlewareChain(handlers.RemoveUserRoleHandler(userService, logger)))
+	v1.Handle("PUT /users/{id}/plan/{plan}", adminMiddlewareChain(handlers.SetUserPlanHandler(userService, logger)))
+	v1.Handle("PUT /users/{id}/password", adminMiddlewareChain(handlers.SetUserPasswordHandler(userService, logger)))
+	v1.Handle("PUT /users/{id}", scopedChain(auth.ScopeUsersWrite)(handlers.UpdateUserHandler(userService, false, logger)))
+	v1.Handle("PATCH /users/{id}", scopedChain(auth.ScopeUsersWrite)(handlers.UpdateUserHandler(userService, true, logger)))
+	v1.Handle("DELETE /users/{id}", scopedChain(auth.ScopeUsersWrite)(handlers.DeleteUserHandler(userService, logger)))
+
+	// Article V1
+	articleService := services.NewArticleService(articleRepo, logger)
+	v1.Handle("POST /articles", scopedChain(auth.ScopeArticlesWrite)(handlers.CreateArticleHandler(articleService, logger)))
+	v1.Handle("GET /articles", scopedChain(auth.ScopeArticlesRead)(handlers.ListArticlesHandler(articleService, logger)))
+	v1.Handle("GET /articles/{id}", scopedChain(auth.ScopeArticlesRead)(handlers.GetArticleByIDHandler(articleService, logger)))
+	v1.Handle("PUT /articles/{id}", scopedChain(auth.ScopeArticlesWrite)(handlers.UpdateArticleHandler(articleService, false, logger)))
+	v1.Handle("PATCH /articles/{id}", scopedChain(auth.ScopeArticlesWrite)(handlers.UpdateArticleHandler(articleService, true, logger)))
+	v1.Handle("DELETE /articles/{id}", scopedChain(auth.ScopeArticlesWrite)(handlers.DeleteArticleHandler(articleService, logger)))
+	v1.Handle("GET /users/{id}/articles", scopedChain(auth.ScopeArticlesRead)(handlers.ListArticlesByAuthorHandler(articleService, logger)))
 
 	apiServer := &http.Server{
-		Addr:    ":" + strconv.Itoa(config.App.Port),
-		Handler: router,
+		Addr:    ":" + strconv.Itoa(cfg.App.Port),
+		Handler: middleware.ChainMiddleware(middleware.RecoveryMiddleware(logger), middleware.MetricsMiddleware())(router),
+	}
+
+	// The admin server is kept on its own port so operational endpoints are
+	// never exposed alongside the public API.
+	adminRouter := http.NewServeMux()
+	adminRouter.Handle("GET /metrics", metrics.Handler())
+	adminRouter.HandleFunc("/debug/pprof/", pprof.Index)
+	adminRouter.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	adminRouter.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	adminRouter.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	adminRouter.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	adminServerChain := middleware.ChainMiddleware(middleware.RequestLoggerMiddleware(logger))
+	adminRouter.Handle("GET /admin/loglevel", adminServerChain(handlers.GetLogLevelHandler(logLevel, logger)))
+	adminRouter.Handle("PUT /admin/loglevel", adminServerChain(handlers.SetLogLevelHandler(logLevel, logger)))
+	adminRouter.Handle("GET /admin/buildinfo", adminServerChain(handlers.BuildInfoHandler(logger)))
+	adminRouter.Handle("GET /admin/config", adminServerChain(handlers.ConfigHandler(configWatcher, logger)))
+	adminRouter.Handle("GET /admin/readyz", adminServerChain(handlers.ReadinessHandler(healthChecker, true, logger)))
+
+	adminServer := &http.Server{
+		Addr:    net.JoinHostPort(cfg.App.AdminHost, strconv.Itoa(cfg.App.AdminPort)),
+		Handler: middleware.RecoveryMiddleware(logger)(adminRouter),
 	}
 
 	go func() {
-		logger.Info("Starting API Server", zap.Int("port", config.App.Port))
+		logger.Info("Starting API Server", zap.Int("port", cfg.App.Port))
 		if err := apiServer.ListenAndServe(); err != http.ErrServerClosed {
 			logger.Fatal("Failed to start API Server", zap.Error(err))
 		}
 	}()
 
+	go func() {
+		logger.Info("Starting Admin Server", zap.String("addr", adminServer.Addr))
+		if err := adminServer.ListenAndServe(); err != http.ErrServerClosed {
+			logger.Fatal("Failed to start Admin Server", zap.Error(err))
+		}
+	}()
+
+	// Changes to config.yaml are applied live; SIGHUP forces a reload, such
+	// as after replacing a JWT key file.
+	configWatcher.Watch()
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			configWatcher.Reload()
+		}
+	}()
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
 	sig := <-quit
 	logger.Info("Shutting down Server...",
 		zap.String("signal", sig.String()),
-		zap.Duration("graceful_shutdown_period", config.App.GracefulShutdownPeriod))
+		zap.Duration("readiness_drain_period", cfg.App.ReadinessDrainPeriod),
+		zap.Duration("graceful_shutdown_period", cfg.App.GracefulShutdownPeriod))
+
+	// Fail readiness first and keep serving while load balancers notice, so
+	// no new traffic arrives once the server stops accepting connections.
+	healthChecker.Drain()
+	time.Sleep(cfg.App.ReadinessDrainPeriod)
 
-	ctx, cancel := context.WithTimeout(context.Background(), config.App.GracefulShutdownPeriod)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.App.GracefulShutdownPeriod)
 	defer cancel()
 
 	if err := apiServer.Shutdown(ctx); err != nil {
 		logger.Fatal("Server forced to shutdown", zap.Error(err))
 	}
+	// The admin server stops last so metrics can be scraped while draining.
+	if err := adminServer.Shutdown(ctx); err != nil {
+		logger.Fatal("Admin Server forced to shutdown", zap.Error(err))
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error("Failed to flush traces", zap.Error(err))
+	}
 
 	logger.Info("Server exited gracefully.")
 }
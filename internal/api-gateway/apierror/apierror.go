@@ -0,0 +1,206 @@
+// Package apierror defines the errors returned to API clients and writes
+// them as RFC 7807 application/problem+json documents.
+package apierror
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ContentType is the media type of problem documents.
+const ContentType = "application/problem+json"
+
+// RequestIDHeader carries the request ID on requests and responses.
+const RequestIDHeader = "X-Request-ID"
+
+// Codes identify the kind of problem independently of the human-readable title.
+const (
+	CodeBadRequest   = "bad_request"
+	CodeValidation   = "validation_failed"
+	CodeUnauthorized = "unauthorized"
+	CodeForbidden    = "forbidden"
+	CodeNotFound     = "not_found"
+	CodeConflict     = "conflict"
+	CodeTooLarge     = "request_too_large"
+	CodeRateLimited  = "rate_limited"
+	CodeInternal     = "internal_error"
+	CodeUnavailable  = "service_unavailable"
+)
+
+// SQLSTATE codes mapped by From.
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgCheckViolation      = "23514"
+	pgInvalidTextFormat   = "22P02"
+)
+
+// conflictDetails describes unique constraints in terms clients understand.
+var conflictDetails = map[string]string{
+	"users_username_key": "username is already taken",
+	"users_email_key":    "email is already registered",
+}
+
+// FieldError describes why one field of a request was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is an error whose Status, Code and Detail are safe to show clients.
+// The underlying cause, if any, is only available through Unwrap.
+type Error struct {
+	Status     int
+	Code       string
+	Detail     string
+	Fields     []FieldError
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Detail + ": " + e.Err.Error()
+	}
+	return e.Detail
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func BadRequest(detail string) *Error {
+	return &Error{Status: http.StatusBadRequest, Code: CodeBadRequest, Detail: detail}
+}
+
+// Validation reports a well-formed request whose fields were rejected.
+func Validation(detail string, fields ...FieldError) *Error {
+	return &Error{Status: http.StatusUnprocessableEntity, Code: CodeValidation, Detail: detail, Fields: fields}
+}
+
+func Unauthorized(detail string) *Error {
+	return &Error{Status: http.StatusUnauthorized, Code: CodeUnauthorized, Detail: detail}
+}
+
+func Forbidden(detail string) *Error {
+	return &Error{Status: http.StatusForbidden, Code: CodeForbidden, Detail: detail}
+}
+
+func NotFound(detail string) *Error {
+	return &Error{Status: http.StatusNotFound, Code: CodeNotFound, Detail: detail}
+}
+
+func Conflict(detail string) *Error {
+	return &Error{Status: http.StatusConflict, Code: CodeConflict, Detail: detail}
+}
+
+func TooLarge(detail string) *Error {
+	return &Error{Status: http.StatusRequestEntityTooLarge, Code: CodeTooLarge, Detail: detail}
+}
+
+// RateLimited reports a rejected request that may be retried after retryAfter.
+func RateLimited(detail string, retryAfter time.Duration) *Error {
+	return &Error{Status: http.StatusTooManyRequests, Code: CodeRateLimited, Detail: detail, RetryAfter: retryAfter}
+}
+
+// Internal hides err from the client behind a generic message.
+func Internal(err error) *Error {
+	return &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Detail: "Internal server error", Err: err}
+}
+
+func Unavailable(detail string) *Error {
+	return &Error{Status: http.StatusServiceUnavailable, Code: CodeUnavailable, Detail: detail}
+}
+
+// From maps err to the Error shown to clients. Errors that already are an
+// *Error are returned as is; known pgx and Postgres errors are translated;
+// anything else becomes Internal.
+func From(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &Error{Status: http.StatusNotFound, Code: CodeNotFound, Detail: "resource not found", Err: err}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &Error{Status: http.StatusServiceUnavailable, Code: CodeUnavailable, Detail: "request timed out", Err: err}
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgUniqueViolation:
+			detail, ok := conflictDetails[pgErr.ConstraintName]
+			if !ok {
+				detail = "resource already exists"
+			}
+			return &Error{Status: http.StatusConflict, Code: CodeConflict, Detail: detail, Err: err}
+		case pgForeignKeyViolation:
+			return &Error{Status: http.StatusConflict, Code: CodeConflict, Detail: "referenced resource does not exist", Err: err}
+		case pgCheckViolation, pgInvalidTextFormat:
+			return &Error{Status: http.StatusUnprocessableEntity, Code: CodeValidation, Detail: "request contains an invalid value", Err: err}
+		}
+	}
+
+	return Internal(err)
+}
+
+// Problem is an RFC 7807 problem details document. Code, RequestID, Errors
+// and RetryAfter are extension members.
+type Problem struct {
+	Type       string       `json:"type"`
+	Title      string       `json:"title"`
+	Status     int          `json:"status"`
+	Detail     string       `json:"detail,omitempty"`
+	Instance   string       `json:"instance,omitempty"`
+	Code       string       `json:"code"`
+	RequestID  string       `json:"request_id,omitempty"`
+	Errors     []FieldError `json:"errors,omitempty"`
+	RetryAfter int          `json:"retry_after,omitempty"`
+}
+
+// Write maps err with From and writes it as a problem document. The request
+// ID is taken from the response's X-Request-ID header, which
+// RequestLoggerMiddleware sets. Server errors also mark the current span of
+// r as failed.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	apiErr := From(err)
+	if apiErr.Status >= http.StatusInternalServerError {
+		span := trace.SpanFromContext(r.Context())
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	problem := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(apiErr.Status),
+		Status:    apiErr.Status,
+		Detail:    apiErr.Detail,
+		Instance:  r.URL.Path,
+		Code:      apiErr.Code,
+		RequestID: w.Header().Get(RequestIDHeader),
+		Errors:    apiErr.Fields,
+	}
+	if apiErr.RetryAfter > 0 {
+		// Retry-After is in whole seconds; round up so clients never retry early.
+		seconds := int((apiErr.RetryAfter + time.Second - 1) / time.Second)
+		problem.RetryAfter = seconds
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(apiErr.Status)
+	json.NewEncoder(w).Encode(problem)
+}
@@ -0,0 +1,107 @@
+// Package auth describes the authenticated caller of a request so that
+// middleware, handlers and services share one view of who is calling.
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type contextKey string
+
+// Roles stored in the roles table.
+const (
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleReader = "reader"
+)
+
+// Plans stored on users. The plan selects rate limits and usage quotas.
+const (
+	PlanFree     = "free"
+	PlanPro      = "pro"
+	PlanInternal = "internal"
+)
+
+// Scopes an API key can be granted. Each route requires one, so a key can
+// only use the routes it was granted even when its owner could use more.
+const (
+	ScopeUsersRead     = "users:read"
+	ScopeUsersWrite    = "users:write"
+	ScopeArticlesRead  = "articles:read"
+	ScopeArticlesWrite = "articles:write"
+	ScopeAPIKeysRead   = "api_keys:read"
+	ScopeAPIKeysWrite  = "api_keys:write"
+	ScopeAdmin         = "admin"
+)
+
+// Scopes lists every scope an API key can be granted.
+var Scopes = []string{
+	ScopeUsersRead,
+	ScopeUsersWrite,
+	ScopeArticlesRead,
+	ScopeArticlesWrite,
+	ScopeAPIKeysRead,
+	ScopeAPIKeysWrite,
+	ScopeAdmin,
+}
+
+// IsScope reports whether scope is one of Scopes.
+func IsScope(scope string) bool {
+	return slices.Contains(Scopes, scope)
+}
+
+const principalContextKey contextKey = "principal"
+
+// ErrUnauthenticated is wrapped by credential checks that fail because the
+// caller presented bad credentials rather than because of an internal error.
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// Method is how a principal authenticated.
+type Method string
+
+const (
+	MethodJWT    Method = "jwt"
+	MethodAPIKey Method = "api_key"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Method   Method
+	UserID   uuid.UUID
+	Email    string
+	Username string
+	Roles    []string
+	Scopes   []string
+	Plan     string
+	// TokenID is the jti of the access token, or the ID of the API key, the
+	// caller authenticated with.
+	TokenID string
+	// ExpiresAt is zero for credentials that never expire.
+	ExpiresAt time.Time
+}
+
+// HasRole reports whether the principal has the given role.
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// HasScope reports whether the principal was granted the given scope.
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey, p)
+}
+
+// PrincipalFromContext returns the authenticated caller, if any.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalContextKey).(*Principal)
+	return p, ok
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/akshaysangma/go-serve/internal/api-gateway/apierror"
+	"github.com/akshaysangma/go-serve/internal/api-gateway/middleware"
+	"github.com/akshaysangma/go-serve/internal/common/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type LogLevelRequest struct {
+	Level string `json:"level" validate:"required"`
+}
+
+type LogLevelResponse struct {
+	Level string `json:"level"`
+}
+
+// BuildInfoResponse describes the running binary.
+type BuildInfoResponse struct {
+	GoVersion string            `json:"go_version"`
+	Path      string            `json:"path"`
+	Version   string            `json:"version"`
+	Settings  map[string]string `json:"settings"`
+	Deps      map[string]string `json:"deps"`
+}
+
+func GetLogLevelHandler(level zap.AtomicLevel, defaultLogger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(LogLevelResponse{Level: level.Level().String()})
+	}
+}
+
+// SetLogLevelHandler changes the level of the application logger at runtime.
+func SetLogLevelHandler(level zap.AtomicLevel, defaultLogger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := middleware.LoggerFromContext(r.Context(), defaultLogger)
+
+		var req LogLevelRequest
+		if err := decodeJSON(w, r, &req); err != nil {
+			logger.Error("Failed to decode log level request", zap.Error(err))
+			apierror.Write(w, r, err)
+			return
+		}
+
+		newLevel, err := zapcore.ParseLevel(req.Level)
+		if err != nil {
+			logger.Error("Invalid log level requested", zap.Error(err))
+			apierror.Write(w, r, apierror.Validation("invalid log level", apierror.FieldError{Field: "level", Message: "must be one of debug, info, warn, error, dpanic, panic, fatal"}))
+			return
+		}
+
+		oldLevel := level.Level()
+		level.SetLevel(newLevel)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(LogLevelResponse{Level: newLevel.String()})
+
+		// Logged at warn so that the change is visible at any level.
+		logger.Warn("Log level changed", zap.Stringer("from", oldLevel), zap.Stringer("to", newLevel))
+	}
+}
+
+func BuildInfoHandler(defaultLogger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger := middleware.LoggerFromContext(r.Context(), defaultLogger)
+
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			logger.Error("Build info is not available")
+			apierror.Write(w, r, apierror.Internal(errors.New("binary was built without module support")))
+			return
+		}
+
+		resp := BuildInfoResponse{
+			GoVersion: info.GoVersion,
+			Path:      info.Main.Path,
+			Version:   info.Main.Version,
+			Settings:  make(map[string]string, len(info.Settings)),
+			Deps:      make(map[string]string, len(info.Deps)),
+		}
+		for _, setting := range info.Settings {
+			resp.Settings[setting.Key] = setting.Value
+		}
+		for _, dep := range info.Deps {
+			resp.Deps[dep.Path] = dep.Version
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// ConfigHandler serves the last good configuration, with secrets redacted.
+// Settings that need a restart may differ from those in effect.
+func ConfigHandler(watcher *config.Watcher, defaultLogger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(watcher.Current().Redacted())
+	}
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/akshaysangma/go-serve/internal/api-gateway/apierror"
+	"github.com/akshaysangma/go-serve/internal/api-gateway/auth"
+	"github.com/akshaysangma/go-serve/internal/api-gateway/middleware"
+	"github.com/akshaysangma/go-serve/internal/api-gateway/services"
+	"github.com/akshaysangma/go-serve/internal/api-gateway/validate"
+	db "github.com/akshaysangma/go-serve/internal/database/postgres/sqlc"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"max=16"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreateAPIKeyResponse is the only response that contains the plaintext key.
+type CreateAPIKeyResponse struct {
+	Key    string    `json:"key"`
+	APIKey db.APIKey `json:"api_key"`
+}
+
+// CreateAPIKeyHandler creates a key for the caller. Keys can only be created
+// from a user session so that a key cannot mint keys with wider scopes.
+func CreateAPIKeyHandler(k *services.APIKeyService, defaultLogger *zap.Logger) http.HandlerFunc {
+	return traced("CreateAPIKeyHandler", func(w http.ResponseWriter, r *http.Request) {
+		logger := middleware.LoggerFromContext(r.Context(), defaultLogger)
+
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			logger.Error("Failed to retrieve authenticated user")
+			apierror.Write(w, r, apierror.Unauthorized("authentication required"))
+			return
+		}
+		if principal.Method != auth.MethodJWT {
+			logger.Warn("API key creation attempted without an access token", zap.String("method", string(principal.Method)))
+			apierror.Write(w, r, apierror.Forbidden("API keys can only be created with an access token"))
+			return
+		}
+
+		var req CreateAPIKeyRequest
+		if err := decodeJSON(w, r, &req); err != nil {
+			logger.Error("Failed to decode create API key request", zap.Error(err))
+			apierror.Write(w, r, err)
+			return
+		}
+
+		key, apiKey, err := k.CreateAPIKey(r.Context(), principal.UserID, req.Name, req.Scopes, req.ExpiresAt)
+		if err != nil {
+			logger.Error("Failed to create API key", zap.Error(err))
+			switch {
+			case errors.Is(err, services.ErrInvalidAPIKeyRequest):
+				apierror.Write(w, r, apierror.Validation(err.Error()))
+				return
+			case errors.Is(err, services.ErrUnknownAPIKeyScope):
+				apierror.Write(w, r, validationError(validate.FieldError{
+					Field:   "scopes",
+					Message: "must only contain " + strings.Join(auth.Scopes, ", "),
+				}))
+				return
+			}
+			apierror.Write(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(CreateAPIKeyResponse{Key: key, APIKey: apiKey})
+
+		logger.Info("API key created successfully", zap.String("api_key_id", apiKey.ID.String()))
+	})
+}
+
+func ListAPIKeysHandler(k *services.APIKeyService, defaultLogger *zap.Logger) http.HandlerFunc {
+	return traced("ListAPIKeysHandler", func(w http.ResponseWriter, r *http.Request) {
+		logger := middleware.LoggerFromContext(r.Context(), defaultLogger)
+
+		userID, err := authenticatedUserID(r)
+		if err != nil {
+			logger.Error("Failed to retrieve authenticated user", zap.Error(err))
+			apierror.Write(w, r, apierror.Unauthorized("authentication required"))
+			return
+		}
+
+		keys, err := k.ListAPIKeys(r.Context(), userID)
+		if err != nil {
+			logger.Error("Failed to list API keys", zap.Error(err))
+			apierror.Write(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(keys)
+
+		logger.Info("API keys retrieved successfully", zap.Int("count", len(keys)))
+	})
+}
+
+func RevokeAPIKeyHandler(k *services.APIKeyService, defaultLogger *zap.Logger) http.HandlerFunc {
+	return traced("RevokeAPIKeyHandler", func(w http.ResponseWriter, r *http.Request) {
+		logger := middleware.LoggerFromContext(r.Context(), defaultLogger)
+
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			logger.Error("Failed to retrieve authenticated user")
+			apierror.Write(w, r, apierror.Unauthorized("authentication required"))
+			return
+		}
+
+		id, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			logger.Error("Failed to retrieve valid API key ID from request", zap.Error(err))
+			apierror.Write(w, r, apierror.BadRequest("invalid API key ID"))
+			return
+		}
+
+		if err := k.RevokeAPIKey(r.Context(), id, principal); err != nil {
+			logger.Error("Failed to revoke API key", zap.Error(err), zap.String("api_key_id", id.String()))
+			switch {
+			case errors.Is(err, pgx.ErrNoRows):
+				apierror.Write(w, r, apierror.NotFound("API key not found"))
+			case errors.Is(err, services.ErrNotAPIKeyOwner):
+				apierror.Write(w, r, apierror.Forbidden("only the owner can revoke this API key"))
+			default:
+				apierror.Write(w, r, err)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+		logger.Info("API key revoked successfully", zap.String("api_key_id", id.String()))
+	})
+}
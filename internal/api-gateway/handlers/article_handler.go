@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/akshaysangma/go-serve/internal/api-gateway/apierror"
+	"github.com/akshaysangma/go-serve/internal/api-gateway/auth"
+	"github.com/akshaysangma/go-serve/internal/api-gateway/middleware"
+	"github.com/akshaysangma/go-serve/internal/api-gateway/services"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+type CreateArticleRequest struct {
+	Title   string `json:"title" validate:"required,max=200"`
+	Content string `json:"content" validate:"required,max=100000"`
+}
+
+// UpdateArticleRequest is shared by PUT and PATCH. PUT requires every field,
+// PATCH only updates the fields present in the body.
+type UpdateArticleRequest struct {
+	Title   *string `json:"title" validate:"min=1,max=200"`
+	Content *string `json:"content" validate:"min=1,max=100000"`
+}
+
+func CreateArticleHandler(a *services.ArticleService, defaultLogger *zap.Logger) http.HandlerFunc {
+	return traced("CreateArticleHandler", func(w http.ResponseWriter, r *http.Request) {
+		logger := middleware.LoggerFromContext(r.Context(), defaultLogger)
+
+		authorID, err := authenticatedUserID(r)
+		if err != nil {
+			logger.Error("Failed to retrieve authenticated user", zap.Error(err))
+			apierror.Write(w, r, apierror.Unauthorized("authentication required"))
+			return
+		}
+
+		var req CreateArticleRequest
+		if err := decodeJSON(w, r, &req); err != nil {
+			logger.Error("Failed to decode create article request", zap.Error(err))
+			apierror.Write(w, r, err)
+			return
+		}
+
+		article, err := a.CreateArticle(r.Context(), req.Title, req.Content, authorID)
+		if err != nil {
+			logger.Error("Failed to create article", zap.Error(err))
+			apierror.Write(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(article)
+
+		logger.Info("Article created successfully", zap.String("article_id", article.ID.String()))
+	})
+}
+
+func GetArticleByIDHandler(a *services.ArticleService, defaultLogger *zap.Logger) http.HandlerFunc {
+	return traced("GetArticleByIDHandler", func(w http.ResponseWriter, r *http.Request) {
+		logger := middleware.LoggerFromContext(r.Context(), defaultLogger)
+		id, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			logger.Error("Failed to retrieve valid Article ID from request", zap.Error(err))
+			apierror.Write(w, r, apierror.BadRequest("invalid article ID"))
+			return
+		}
+
+		article, err := a.GetArticleByID(r.Context(), id)
+		if err != nil {
+			logger.Error("Failed to get article by ID", zap.Error(err), zap.String("article_id", id.String()))
+			writeArticleError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(article)
+
+		logger.Info("Article retrieved successfully", zap.String("article_id", article.ID.String()))
+	})
+}
+
+// ListArticlesHandler returns a page of articles. Query parameters: limit,
+// cursor, sort, author, created_after and created_before.
+func ListArticlesHandler(a *services.ArticleService, defaultLogger *zap.Logger) http.HandlerFunc {
+	return traced("ListArticlesHandler", func(w http.ResponseWriter, r *http.Request) {
+		logger := middleware.LoggerFromContext(r.Context(), defaultLogger)
+
+		query := newListQuery(r.URL.Query())
+		filter := services.ArticleFilter{
+			AuthorID:      query.optionalUUID("author"),
+			CreatedAfter:  query.optionalTime("created_after"),
+			CreatedBefore: query.optionalTime("created_before"),
+		}
+		listArticles(w, r, a, filter, query, logger)
+	})
+}
+
+func ListArticlesByAuthorHandler(a *services.ArticleService, defaultLogger *zap.Logger) http.HandlerFunc {
+	return traced("ListArticlesByAuthorHandler", func(w http.ResponseWriter, r *http.Request) {
+		logger := middleware.LoggerFromContext(r.Context(), defaultLogger)
+		authorID, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			logger.Error("Failed to retrieve valid User ID from request", zap.Error(err))
+			apierror.Write(w, r, apierror.BadRequest("invalid user ID"))
+			return
+		}
+
+		query := newListQuery(r.URL.Query())
+		filter := services.ArticleFilter{
+			AuthorID:      &authorID,
+			CreatedAfter:  query.optionalTime("created_after"),
+			CreatedBefore: query.optionalTime("created_before"),
+		}
+		listArticles(w, r, a, filter, query, logger.With(zap.String("author_id", authorID.String())))
+	})
+}
+
+// listArticles writes the page of articles selected by filter and query.
+func listArticles(w http.ResponseWriter, r *http.Request, a *services.ArticleService, filter services.ArticleFilter, query *listQuery, logger *zap.Logger) {
+	page := query.page()
+	if err := query.err(); err != nil {
+		logger.Error("Invalid list articles query", zap.Error(err))
+		apierror.Write(w, r, err)
+		return
+	}
+
+	articles, err := a.ListArticles(r.Context(), filter, page)
+	if err != nil {
+		logger.Error("Fail to fetch articles", zap.Error(err))
+		if errors.Is(err, services.ErrInvalidCursor) {
+			apierror.Write(w, r, cursorError())
+			return
+		}
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(articles)
+
+	logger.Info("Articles retrieved successfully", zap.Int("count", len(articles.Items)))
+}
+
+// UpdateArticleHandler serves both PUT and PATCH; partial selects PATCH semantics.
+func UpdateArticleHandler(a *services.ArticleService, partial bool, defaultLogger *zap.Logger) http.HandlerFunc {
+	return traced("UpdateArticleHandler", func(w http.ResponseWriter, r *http.Request) {
+		logger := middleware.LoggerFromContext(r.Context(), defaultLogger)
+
+		authorID, err := authenticatedUserID(r)
+		if err != nil {
+			logger.Error("Failed to retrieve authenticated user", zap.Error(err))
+			apierror.Write(w, r, apierror.Unauthorized("authentication required"))
+			return
+		}
+
+		id, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			logger.Error("Failed to retrieve valid Article ID from request", zap.Error(err))
+			apierror.Write(w, r, apierror.BadRequest("invalid article ID"))
+			return
+		}
+
+		var req UpdateArticleRequest
+		if err := decodeJSON(w, r, &req); err != nil {
+			logger.Error("Failed to decode update article request", zap.Error(err))
+			apierror.Write(w, r, err)
+			return
+		}
+		if !partial {
+			if fields := missingFields(map[string]bool{"title": req.Title != nil, "content": req.Content != nil}); len(fields) > 0 {
+				logger.Error("Incomplete article replacement request", zap.String("article_id", id.String()))
+				apierror.Write(w, r, validationError(fields...))
+				return
+			}
+		}
+
+		article, err := a.UpdateArticle(r.Context(), id, authorID, req.Title, req.Content)
+		if err != nil {
+			logger.Error("Failed to update article", zap.Error(err), zap.String("article_id", id.String()))
+			writeArticleError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(article)
+
+		logger.Info("Article updated successfully", zap.String("article_id", article.ID.String()))
+	})
+}
+
+func DeleteArticleHandler(a *services.ArticleService, defaultLogger *zap.Logger) http.HandlerFunc {
+	return traced("DeleteArticleHandler", func(w http.ResponseWriter, r *http.Request) {
+		logger := middleware.LoggerFromContext(r.Context(), defaultLogger)
+
+		authorID, err := authenticatedUserID(r)
+		if err != nil {
+			logger.Error("Failed to retrieve authenticated user", zap.Error(err))
+			apierror.Write(w, r, apierror.Unauthorized("authentication required"))
+			return
+		}
+
+		id, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			logger.Error("Failed to retrieve valid Article ID from request", zap.Error(err))
+			apierror.Write(w, r, apierror.BadRequest("invalid article ID"))
+			return
+		}
+
+		if err := a.DeleteArticle(r.Context(), id, authorID); err != nil {
+			logger.Error("Failed to delete article", zap.Error(err), zap.String("article_id", id.String()))
+			writeArticleError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+		logger.Info("Article deleted successfully", zap.String("article_id", id.String()))
+	})
+}
+
+// authenticatedUserID returns the user ID of the principal set by AuthMiddleware.
+func authenticatedUserID(r *http.Request) (uuid.UUID, error) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		return uuid.Nil, errors.New("no principal in request context")
+	}
+	return principal.UserID, nil
+}
+
+func writeArticleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		apierror.Write(w, r, apierror.NotFound("article not found"))
+	case errors.Is(err, services.ErrNotArticleAuthor):
+		apierror.Write(w, r, apierror.Forbidden("only the author can modify this article"))
+	default:
+		apierror.Write(w, r, err)
+	}
+}
@@ -2,15 +2,45 @@
 package handlers
 
 import (
-	"fmt"
+	"encoding/json"
 	"net/http"
 
+	"github.com/akshaysangma/go-serve/internal/common/health"
 	"go.uber.org/zap"
 )
 
-func Healthcheck(logger *zap.Logger) http.HandlerFunc {
+// LivenessHandler reports that the process is serving requests. It does not
+// check dependencies, so an outage of one never gets the process restarted.
+func LivenessHandler(logger *zap.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
 		w.WriteHeader(http.StatusOK)
-		fmt.Fprintln(w, "OK")
+		json.NewEncoder(w).Encode(map[string]string{"status": health.StatusUp})
+	}
+}
+
+// ReadinessHandler reports whether the dependencies needed to serve traffic
+// are available, with the status of each check. It fails with 503 while the
+// server is draining for shutdown. Errors and timings of the checks are only
+// included if detailed is set, which is meant for the admin server; they are
+// always logged when readiness fails.
+func ReadinessHandler(c *health.Checker, detailed bool, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := c.Ready(r.Context())
+
+		status := http.StatusOK
+		if report.Status != health.StatusUp {
+			status = http.StatusServiceUnavailable
+			logger.Warn("Readiness check failed", zap.Any("components", report.Components))
+		}
+		if !detailed {
+			report = report.Summary()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(report)
 	}
 }
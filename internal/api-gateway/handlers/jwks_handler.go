@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/akshaysangma/go-serve/internal/common/jwtkeys"
+	"go.uber.org/zap"
+)
+
+// JWKSHandler publishes the public verification keys so other services can
+// validate tokens without holding the signing key.
+func JWKSHandler(keys *jwtkeys.Keyring, logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.WriteHeader(http.StatusOK)
+		if err := json.NewEncoder(w).Encode(keys.JWKS()); err != nil {
+			logger.Error("Failed to encode JWKS", zap.Error(err))
+		}
+	}
+}
@@ -2,69 +2,223 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
+	"github.com/akshaysangma/go-serve/internal/api-gateway/apierror"
+	"github.com/akshaysangma/go-serve/internal/api-gateway/auth"
 	"github.com/akshaysangma/go-serve/internal/api-gateway/middleware"
 	"github.com/akshaysangma/go-serve/internal/api-gateway/services"
 	"github.com/akshaysangma/go-serve/internal/common/config"
+	"github.com/akshaysangma/go-serve/internal/common/jwtkeys"
+	db "github.com/akshaysangma/go-serve/internal/database/postgres/sqlc"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
 }
 
-func LoginHandler(config config.JWTConfig, u *services.UserService, defaultLogger *zap.Logger) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// LogoutRequest optionally carries the refresh token of the session so it is
+// revoked together with the access token.
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func LoginHandler(config config.JWTConfig, keys *jwtkeys.Keyring, u *services.UserService, t *services.RefreshTokenService, defaultLogger *zap.Logger) http.HandlerFunc {
+	return traced("LoginHandler", func(w http.ResponseWriter, r *http.Request) {
 
 		logger := middleware.LoggerFromContext(r.Context(), defaultLogger)
-		userIDStr := r.URL.Query().Get("user")
-		userID, err := uuid.Parse(userIDStr)
+
+		var req LoginRequest
+		if err := decodeJSON(w, r, &req); err != nil {
+			logger.Error("Failed to decode login request", zap.Error(err))
+			apierror.Write(w, r, err)
+			return
+		}
+
+		user, err := u.Authenticate(r.Context(), req.Email, req.Password)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidCredentials) {
+				logger.Warn("Login rejected", zap.Error(err))
+				apierror.Write(w, r, apierror.Unauthorized("invalid email or password"))
+				return
+			}
+			logger.Error("Failed to authenticate user", zap.Error(err))
+			apierror.Write(w, r, apierror.Internal(err))
+			return
+		}
+
+		roles, err := u.GetUserRoles(r.Context(), user.ID)
 		if err != nil {
-			logger.Error("bad user ID provided", zap.Error(err), zap.String("user_id", userIDStr))
-			http.Error(w, "Bad user ID provided", http.StatusBadRequest)
+			logger.Error("Failed to get user roles", zap.Error(err), zap.String("user_id", user.ID.String()))
+			apierror.Write(w, r, apierror.Internal(err))
+			return
+		}
+
+		tokenStr, err := signAccessToken(config, keys, user, roles)
+		if err != nil {
+			logger.Error("unable to sign token", zap.Error(err))
+			apierror.Write(w, r, apierror.Internal(err))
+			return
+		}
+
+		refreshToken, err := t.Issue(r.Context(), user.ID)
+		if err != nil {
+			logger.Error("unable to issue refresh token", zap.Error(err))
+			apierror.Write(w, r, apierror.Internal(err))
+			return
+		}
+
+		res := LoginResponse{
+			Token:        tokenStr,
+			RefreshToken: refreshToken,
+		}
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(res)
+
+		logger.Info("User logged in", zap.String("user_id", user.ID.String()))
+	})
+}
+
+// RefreshHandler rotates a refresh token and issues a new access token for its owner.
+func RefreshHandler(config config.JWTConfig, keys *jwtkeys.Keyring, u *services.UserService, t *services.RefreshTokenService, defaultLogger *zap.Logger) http.HandlerFunc {
+	return traced("RefreshHandler", func(w http.ResponseWriter, r *http.Request) {
+		logger := middleware.LoggerFromContext(r.Context(), defaultLogger)
+
+		var req RefreshRequest
+		if err := decodeJSON(w, r, &req); err != nil {
+			logger.Error("Failed to decode refresh request", zap.Error(err))
+			apierror.Write(w, r, err)
+			return
+		}
+
+		refreshToken, userID, err := t.Rotate(r.Context(), req.RefreshToken)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidRefreshToken) || errors.Is(err, services.ErrRefreshTokenReused) {
+				logger.Warn("Refresh rejected", zap.Error(err))
+				apierror.Write(w, r, apierror.Unauthorized("invalid or expired refresh token"))
+				return
+			}
+			logger.Error("Failed to rotate refresh token", zap.Error(err))
+			apierror.Write(w, r, apierror.Internal(err))
 			return
 		}
 
-		// mimicing credentials validation and get user obj from third party or database
 		user, err := u.GetUserByID(r.Context(), userID)
 		if err != nil {
-			logger.Error("user not exist", zap.Error(err), zap.String("user_id", userIDStr))
-			http.Error(w, "user not exist", http.StatusNotFound)
+			logger.Error("Failed to get refresh token owner", zap.Error(err), zap.String("user_id", userID.String()))
+			apierror.Write(w, r, apierror.Internal(err))
 			return
 		}
 
-		expirationTime := time.Now().Add(config.ExpirationDuration)
-		claims := &middleware.AuthClaims{
-			UserID:   user.ID.String(),
-			Email:    user.Email,
-			Username: user.Username,
-			RegisteredClaims: jwt.RegisteredClaims{
-				Issuer:    "go-serve",
-				IssuedAt:  jwt.NewNumericDate(time.Now()),
-				ExpiresAt: jwt.NewNumericDate(expirationTime),
-				NotBefore: jwt.NewNumericDate(time.Now()),
-				Subject:   user.ID.String(),
-				Audience:  []string{"web"},
-			},
+		roles, err := u.GetUserRoles(r.Context(), user.ID)
+		if err != nil {
+			logger.Error("Failed to get user roles", zap.Error(err), zap.String("user_id", user.ID.String()))
+			apierror.Write(w, r, apierror.Internal(err))
+			return
 		}
 
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-		tokenStr, err := token.SignedString([]byte(config.Secret))
+		tokenStr, err := signAccessToken(config, keys, user, roles)
 		if err != nil {
 			logger.Error("unable to sign token", zap.Error(err))
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			apierror.Write(w, r, apierror.Internal(err))
 			return
 		}
 
 		res := LoginResponse{
-			Token: tokenStr,
+			Token:        tokenStr,
+			RefreshToken: refreshToken,
 		}
-		w.WriteHeader(http.StatusOK)
 		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(res)
+
+		logger.Info("Tokens refreshed", zap.String("user_id", user.ID.String()))
+	})
+}
+
+// LogoutHandler revokes the access token used to call it and, if supplied,
+// the refresh token family of the session.
+func LogoutHandler(rs *services.TokenRevocationService, t *services.RefreshTokenService, defaultLogger *zap.Logger) http.HandlerFunc {
+	return traced("LogoutHandler", func(w http.ResponseWriter, r *http.Request) {
+		logger := middleware.LoggerFromContext(r.Context(), defaultLogger)
+
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			logger.Error("Failed to retrieve principal for logout")
+			apierror.Write(w, r, apierror.Unauthorized("authentication required"))
+			return
+		}
+		if principal.Method != auth.MethodJWT {
+			logger.Warn("Logout attempted without an access token", zap.String("method", string(principal.Method)))
+			apierror.Write(w, r, apierror.BadRequest("logout requires an access token; revoke API keys instead"))
+			return
+		}
+
+		var req LogoutRequest
+		if r.ContentLength != 0 {
+			if err := decodeJSON(w, r, &req); err != nil {
+				logger.Error("Failed to decode logout request", zap.Error(err))
+				apierror.Write(w, r, err)
+				return
+			}
+		}
+
+		if err := rs.Revoke(r.Context(), principal.TokenID, principal.ExpiresAt); err != nil {
+			logger.Error("Failed to revoke access token", zap.Error(err))
+			apierror.Write(w, r, apierror.Internal(err))
+			return
+		}
+
+		if req.RefreshToken != "" {
+			if err := t.Revoke(r.Context(), req.RefreshToken); err != nil {
+				logger.Error("Failed to revoke refresh token", zap.Error(err))
+				apierror.Write(w, r, apierror.Internal(err))
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+		logger.Info("User logged out")
+	})
+}
+
+// signAccessToken issues a JWT for user carrying roles, valid for
+// config.ExpirationDuration and signed with the active key in keys.
+func signAccessToken(config config.JWTConfig, keys *jwtkeys.Keyring, user db.User, roles []string) (string, error) {
+	now := time.Now()
+	claims := &middleware.AuthClaims{
+		UserID:   user.ID.String(),
+		Email:    user.Email,
+		Username: user.Username,
+		Roles:    roles,
+		Plan:     user.Plan,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "go-serve",
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(config.ExpirationDuration)),
+			NotBefore: jwt.NewNumericDate(now),
+			Subject:   user.ID.String(),
+			Audience:  []string{"web"},
+			ID:        uuid.NewString(),
+		},
 	}
+
+	return keys.Sign(claims)
 }
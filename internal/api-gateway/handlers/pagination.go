@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/akshaysangma/go-serve/internal/api-gateway/services"
+	"github.com/akshaysangma/go-serve/internal/api-gateway/validate"
+	"github.com/google/uuid"
+)
+
+// Values of the sort query parameter accepted by list endpoints.
+const (
+	sortCreatedAtAsc  = "created_at"
+	sortCreatedAtDesc = "-created_at"
+)
+
+// listQuery reads the query parameters of a list request, collecting every
+// invalid parameter so they can be reported together.
+type listQuery struct {
+	values url.Values
+	fields []validate.FieldError
+}
+
+func newListQuery(values url.Values) *listQuery {
+	return &listQuery{values: values}
+}
+
+// page reads the limit, cursor and sort parameters.
+func (q *listQuery) page() services.PageRequest {
+	page := services.PageRequest{Cursor: q.values.Get("cursor")}
+
+	if raw := q.values.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > services.MaxPageLimit {
+			q.invalid("limit", fmt.Sprintf("must be an integer between 1 and %d", services.MaxPageLimit))
+		}
+		page.Limit = limit
+	}
+
+	switch q.values.Get("sort") {
+	case "", sortCreatedAtDesc:
+	case sortCreatedAtAsc:
+		page.Ascending = true
+	default:
+		q.invalid("sort", "must be one of "+sortCreatedAtAsc+", "+sortCreatedAtDesc)
+	}
+	return page
+}
+
+// optionalString returns the parameter name, or nil if it is absent or empty.
+func (q *listQuery) optionalString(name string) *string {
+	raw := q.values.Get(name)
+	if raw == "" {
+		return nil
+	}
+	return &raw
+}
+
+// optionalTime parses the RFC 3339 parameter name, or returns nil if it is absent.
+func (q *listQuery) optionalTime(name string) *time.Time {
+	raw := q.values.Get(name)
+	if raw == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		q.invalid(name, "must be an RFC 3339 timestamp")
+		return nil
+	}
+	return &t
+}
+
+// optionalUUID parses the parameter name, or returns nil if it is absent.
+func (q *listQuery) optionalUUID(name string) *uuid.UUID {
+	raw := q.values.Get(name)
+	if raw == "" {
+		return nil
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		q.invalid(name, "must be a UUID")
+		return nil
+	}
+	return &id
+}
+
+func (q *listQuery) invalid(name, message string) {
+	q.fields = append(q.fields, validate.FieldError{Field: name, Message: message})
+}
+
+// err returns the validation error for the invalid parameters, if any.
+func (q *listQuery) err() error {
+	if len(q.fields) == 0 {
+		return nil
+	}
+	return validationError(q.fields...)
+}
+
+// cursorError is the client error for a cursor the service rejected.
+func cursorError() error {
+	return validationError(validate.FieldError{Field: "cursor", Message: "is not a valid page cursor"})
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/akshaysangma/go-serve/internal/api-gateway/apierror"
+	"github.com/akshaysangma/go-serve/internal/api-gateway/validate"
+)
+
+// maxRequestBodyBytes bounds every JSON request body.
+const maxRequestBodyBytes = 1 << 20
+
+// decodeJSON strictly decodes the body of r into dst and validates it. The
+// body must hold exactly one JSON object without unknown fields and be at
+// most maxRequestBodyBytes long. The returned error is an *apierror.Error
+// ready to be written.
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return apierror.BadRequest("request body must contain a single JSON object")
+	}
+
+	if err := validate.Struct(dst); err != nil {
+		var verrs validate.Errors
+		if errors.As(err, &verrs) {
+			return validationError(verrs...)
+		}
+		return apierror.Internal(err)
+	}
+	return nil
+}
+
+// validationError converts validation failures to the client error.
+func validationError(verrs ...validate.FieldError) *apierror.Error {
+	fields := make([]apierror.FieldError, len(verrs))
+	for i, fe := range verrs {
+		fields[i] = apierror.FieldError{Field: fe.Field, Message: fe.Message}
+	}
+	return apierror.Validation("request validation failed", fields...)
+}
+
+func decodeError(err error) *apierror.Error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return apierror.TooLarge(fmt.Sprintf("request body must not exceed %d bytes", maxBytesErr.Limit))
+	case errors.As(err, &syntaxErr):
+		return apierror.BadRequest(fmt.Sprintf("request body contains malformed JSON at offset %d", syntaxErr.Offset))
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return apierror.BadRequest("request body contains malformed JSON")
+	case errors.As(err, &typeErr):
+		return validationError(validate.FieldError{Field: typeErr.Field, Message: "must be of type " + typeErr.Type.String()})
+	case errors.Is(err, io.EOF):
+		return apierror.BadRequest("request body must not be empty")
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no typed error for unknown fields.
+		return apierror.BadRequest("request body contains unknown field " + strings.TrimPrefix(err.Error(), "json: unknown field "))
+	default:
+		return apierror.BadRequest("request body is not valid JSON")
+	}
+}
+
+// missingFields returns a "is required" error for every field of present that
+// is false, in name order. PUT handlers use it since the same request struct
+// serves PATCH, where absent fields are allowed.
+func missingFields(present map[string]bool) []validate.FieldError {
+	var fields []validate.FieldError
+	for _, name := range slices.Sorted(maps.Keys(present)) {
+		if !present[name] {
+			fields = append(fields, validate.FieldError{Field: name, Message: "is required"})
+		}
+	}
+	return fields
+}
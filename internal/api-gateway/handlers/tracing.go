@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+)
+
+const instrumentationName = "github.com/akshaysangma/go-serve/internal/api-gateway/handlers"
+
+var tracer = otel.Tracer(instrumentationName)
+
+// traced runs h in a span named name, so a trace shows the time spent in the
+// handler apart from the middlewares before it.
+func traced(name string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracer.Start(r.Context(), name)
+		defer span.End()
+		h(w, r.WithContext(ctx))
+	}
+}
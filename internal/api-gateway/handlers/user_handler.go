@@ -5,6 +5,8 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/akshaysangma/go-serve/internal/api-gateway/apierror"
+	"github.com/akshaysangma/go-serve/internal/api-gateway/auth"
 	"github.com/akshaysangma/go-serve/internal/api-gateway/middleware"
 	"github.com/akshaysangma/go-serve/internal/api-gateway/services"
 	"github.com/google/uuid"
@@ -13,30 +15,42 @@ import (
 )
 
 type CreateUserRequest struct {
-	Username string `json:"username"`
-	Email    string `json:"email"`
+	Username string `json:"username" validate:"required,min=3,max=32,pattern=username"`
+	Email    string `json:"email" validate:"required,max=254,email"`
+	Password string `json:"password" validate:"required"`
 }
 
+// UpdateUserRequest is shared by PUT and PATCH. PUT requires every field,
+// PATCH only updates the fields present in the body.
 type UpdateUserRequest struct {
-	Username string `json:"username"`
-	Email    string `json:"email"`
+	Username *string `json:"username" validate:"min=3,max=32,pattern=username"`
+	Email    *string `json:"email" validate:"max=254,email"`
+}
+
+// SetPasswordRequest carries the new password of a user.
+type SetPasswordRequest struct {
+	Password string `json:"password" validate:"required"`
 }
 
 func CreateUserHandler(u *services.UserService, defaultLogger *zap.Logger) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
+	return traced("CreateUserHandler", func(w http.ResponseWriter, r *http.Request) {
 		logger := middleware.LoggerFromContext(r.Context(), defaultLogger)
 
 		var req CreateUserRequest
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if err := decodeJSON(w, r, &req); err != nil {
 			logger.Error("Failed to decode create user request", zap.Error(err))
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			apierror.Write(w, r, err)
 			return
 		}
 
-		user, articles, err := u.CreateUserTX(r.Context(), req.Username, req.Email)
+		user, articles, err := u.CreateUserTX(r.Context(), req.Username, req.Email, req.Password)
 		if err != nil {
 			logger.Error("Failed to create user and article transactionally", zap.Error(err))
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			if errors.Is(err, services.ErrInvalidPassword) {
+				apierror.Write(w, r, apierror.Validation(err.Error(), apierror.FieldError{Field: "password", Message: err.Error()}))
+				return
+			}
+			apierror.Write(w, r, err)
 			return
 		}
 
@@ -48,16 +62,16 @@ func CreateUserHandler(u *services.UserService, defaultLogger *zap.Logger) http.
 		})
 
 		logger.Info("User and default article created successfully", zap.String("user_id", user.ID.String()))
-	}
+	})
 }
 
 func GetUserByIDHandler(u *services.UserService, defaultLogger *zap.Logger) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
+	return traced("GetUserByIDHandler", func(w http.ResponseWriter, r *http.Request) {
 		logger := middleware.LoggerFromContext(r.Context(), defaultLogger)
 		id, err := uuid.Parse(r.PathValue("id"))
 		if err != nil {
 			logger.Error("Failed to retrive valid User ID from request", zap.Error(err))
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			apierror.Write(w, r, apierror.BadRequest("invalid user ID"))
 			return
 		}
 
@@ -65,27 +79,48 @@ func GetUserByIDHandler(u *services.UserService, defaultLogger *zap.Logger) http
 		if err != nil {
 			logger.Error("Failed to get user bu ID", zap.Error(err), zap.String("user_id", id.String()))
 			if errors.Is(err, pgx.ErrNoRows) {
-				http.Error(w, "User Not Found", http.StatusNotFound)
+				apierror.Write(w, r, apierror.NotFound("user not found"))
 				return
 			}
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			apierror.Write(w, r, err)
+			return
 		}
 		w.Header().Add("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(user)
 
 		logger.Info("User retrieved successfully", zap.String("user_id", user.ID.String()))
-	}
+	})
 }
 
+// ListUsersHandler returns a page of users. Query parameters: limit, cursor,
+// sort, username_prefix, email_domain, created_after and created_before.
 func ListUsersHandler(u *services.UserService, defaultLogger *zap.Logger) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
+	return traced("ListUsersHandler", func(w http.ResponseWriter, r *http.Request) {
 		logger := middleware.LoggerFromContext(r.Context(), defaultLogger)
 
-		users, err := u.ListUsers(r.Context())
+		query := newListQuery(r.URL.Query())
+		filter := services.UserFilter{
+			UsernamePrefix: query.optionalString("username_prefix"),
+			EmailDomain:    query.optionalString("email_domain"),
+			CreatedAfter:   query.optionalTime("created_after"),
+			CreatedBefore:  query.optionalTime("created_before"),
+		}
+		page := query.page()
+		if err := query.err(); err != nil {
+			logger.Error("Invalid list users query", zap.Error(err))
+			apierror.Write(w, r, err)
+			return
+		}
+
+		users, err := u.ListUsers(r.Context(), filter, page)
 		if err != nil {
-			logger.Error("Fail to fetch all users", zap.Error(err))
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			logger.Error("Fail to fetch users", zap.Error(err))
+			if errors.Is(err, services.ErrInvalidCursor) {
+				apierror.Write(w, r, cursorError())
+				return
+			}
+			apierror.Write(w, r, err)
 			return
 		}
 
@@ -93,6 +128,216 @@ func ListUsersHandler(u *services.UserService, defaultLogger *zap.Logger) http.H
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(users)
 
-		logger.Info("All users retrieved successfully", zap.Int("count", len(users)))
+		logger.Info("Users retrieved successfully", zap.Int("count", len(users.Items)))
+	})
+}
+
+// UpdateUserHandler serves both PUT and PATCH; partial selects PATCH semantics.
+func UpdateUserHandler(u *services.UserService, partial bool, defaultLogger *zap.Logger) http.HandlerFunc {
+	return traced("UpdateUserHandler", func(w http.ResponseWriter, r *http.Request) {
+		logger := middleware.LoggerFromContext(r.Context(), defaultLogger)
+
+		id, ok := authorizeSelf(w, r, logger)
+		if !ok {
+			return
+		}
+
+		var req UpdateUserRequest
+		if err := decodeJSON(w, r, &req); err != nil {
+			logger.Error("Failed to decode update user request", zap.Error(err))
+			apierror.Write(w, r, err)
+			return
+		}
+		if !partial {
+			if fields := missingFields(map[string]bool{"username": req.Username != nil, "email": req.Email != nil}); len(fields) > 0 {
+				logger.Error("Incomplete user replacement request", zap.String("user_id", id.String()))
+				apierror.Write(w, r, validationError(fields...))
+				return
+			}
+		}
+
+		user, err := u.UpdateUser(r.Context(), id, req.Username, req.Email)
+		if err != nil {
+			logger.Error("Failed to update user", zap.Error(err), zap.String("user_id", id.String()))
+			if errors.Is(err, pgx.ErrNoRows) {
+				apierror.Write(w, r, apierror.NotFound("user not found"))
+				return
+			}
+			apierror.Write(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(user)
+
+		logger.Info("User updated successfully", zap.String("user_id", user.ID.String()))
+	})
+}
+
+func DeleteUserHandler(u *services.UserService, defaultLogger *zap.Logger) http.HandlerFunc {
+	return traced("DeleteUserHandler", func(w http.ResponseWriter, r *http.Request) {
+		logger := middleware.LoggerFromContext(r.Context(), defaultLogger)
+
+		id, ok := authorizeSelf(w, r, logger)
+		if !ok {
+			return
+		}
+
+		if err := u.DeleteUser(r.Context(), id); err != nil {
+			logger.Error("Failed to delete user", zap.Error(err), zap.String("user_id", id.String()))
+			apierror.Write(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+		logger.Info("User deleted successfully", zap.String("user_id", id.String()))
+	})
+}
+
+func AssignUserRoleHandler(u *services.UserService, defaultLogger *zap.Logger) http.HandlerFunc {
+	return traced("AssignUserRoleHandler", func(w http.ResponseWriter, r *http.Request) {
+		logger := middleware.LoggerFromContext(r.Context(), defaultLogger)
+		id, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			logger.Error("Failed to retrive valid User ID from request", zap.Error(err))
+			apierror.Write(w, r, apierror.BadRequest("invalid user ID"))
+			return
+		}
+		role := r.PathValue("role")
+
+		if err := u.AssignRole(r.Context(), id, role); err != nil {
+			logger.Error("Failed to assign role", zap.Error(err), zap.String("user_id", id.String()), zap.String("role", role))
+			switch {
+			case errors.Is(err, services.ErrUnknownRole):
+				apierror.Write(w, r, apierror.Validation("unknown role"))
+			case errors.Is(err, pgx.ErrNoRows):
+				apierror.Write(w, r, apierror.NotFound("user not found"))
+			default:
+				apierror.Write(w, r, err)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func RemoveUserRoleHandler(u *services.UserService, defaultLogger *zap.Logger) http.HandlerFunc {
+	return traced("RemoveUserRoleHandler", func(w http.ResponseWriter, r *http.Request) {
+		logger := middleware.LoggerFromContext(r.Context(), defaultLogger)
+		id, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			logger.Error("Failed to retrive valid User ID from request", zap.Error(err))
+			apierror.Write(w, r, apierror.BadRequest("invalid user ID"))
+			return
+		}
+		role := r.PathValue("role")
+
+		if err := u.RemoveRole(r.Context(), id, role); err != nil {
+			logger.Error("Failed to remove role", zap.Error(err), zap.String("user_id", id.String()), zap.String("role", role))
+			apierror.Write(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// SetUserPlanHandler moves a user to another plan, changing their rate limits and quotas.
+func SetUserPlanHandler(u *services.UserService, defaultLogger *zap.Logger) http.HandlerFunc {
+	return traced("SetUserPlanHandler", func(w http.ResponseWriter, r *http.Request) {
+		logger := middleware.LoggerFromContext(r.Context(), defaultLogger)
+		id, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			logger.Error("Failed to retrive valid User ID from request", zap.Error(err))
+			apierror.Write(w, r, apierror.BadRequest("invalid user ID"))
+			return
+		}
+		plan := r.PathValue("plan")
+
+		user, err := u.SetPlan(r.Context(), id, plan)
+		if err != nil {
+			logger.Error("Failed to set plan", zap.Error(err), zap.String("user_id", id.String()), zap.String("plan", plan))
+			switch {
+			case errors.Is(err, services.ErrUnknownPlan):
+				apierror.Write(w, r, apierror.Validation("unknown plan"))
+			case errors.Is(err, pgx.ErrNoRows):
+				apierror.Write(w, r, apierror.NotFound("user not found"))
+			default:
+				apierror.Write(w, r, err)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(user)
+	})
+}
+
+// SetUserPasswordHandler replaces the password of a user. It is admin only,
+// since it does not ask for the current password.
+func SetUserPasswordHandler(u *services.UserService, defaultLogger *zap.Logger) http.HandlerFunc {
+	return traced("SetUserPasswordHandler", func(w http.ResponseWriter, r *http.Request) {
+		logger := middleware.LoggerFromContext(r.Context(), defaultLogger)
+		id, err := uuid.Parse(r.PathValue("id"))
+		if err != nil {
+			logger.Error("Failed to retrive valid User ID from request", zap.Error(err))
+			apierror.Write(w, r, apierror.BadRequest("invalid user ID"))
+			return
+		}
+
+		var req SetPasswordRequest
+		if err := decodeJSON(w, r, &req); err != nil {
+			logger.Error("Failed to decode set password request", zap.Error(err))
+			apierror.Write(w, r, err)
+			return
+		}
+
+		if err := u.SetPassword(r.Context(), id, req.Password); err != nil {
+			logger.Error("Failed to set password", zap.Error(err), zap.String("user_id", id.String()))
+			switch {
+			case errors.Is(err, services.ErrInvalidPassword):
+				apierror.Write(w, r, apierror.Validation(err.Error(), apierror.FieldError{Field: "password", Message: err.Error()}))
+			case errors.Is(err, pgx.ErrNoRows):
+				apierror.Write(w, r, apierror.NotFound("user not found"))
+			default:
+				apierror.Write(w, r, err)
+			}
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+
+		logger.Info("Password set", zap.String("user_id", id.String()))
+	})
+}
+
+// authorizeSelf parses the {id} path value and ensures it is the authenticated
+// user or that the caller is an admin. It writes the error response itself and
+// reports whether the handler may continue.
+func authorizeSelf(w http.ResponseWriter, r *http.Request, logger *zap.Logger) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		logger.Error("Failed to retrive valid User ID from request", zap.Error(err))
+		apierror.Write(w, r, apierror.BadRequest("invalid user ID"))
+		return uuid.Nil, false
+	}
+
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		logger.Error("Failed to retrieve authenticated user")
+		apierror.Write(w, r, apierror.Unauthorized("authentication required"))
+		return uuid.Nil, false
 	}
+
+	if principal.UserID != id && !principal.HasRole(auth.RoleAdmin) {
+		logger.Warn("Rejected modification of another user", zap.String("user_id", id.String()), zap.String("caller_id", principal.UserID.String()))
+		apierror.Write(w, r, apierror.Forbidden("users may only modify their own account"))
+		return uuid.Nil, false
+	}
+
+	return id, true
 }
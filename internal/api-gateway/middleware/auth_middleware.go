@@ -2,61 +2,143 @@ package middleware
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"net/http"
+	"time"
 
+	"github.com/akshaysangma/go-serve/internal/api-gateway/apierror"
+	"github.com/akshaysangma/go-serve/internal/api-gateway/auth"
+	"github.com/akshaysangma/go-serve/internal/common/jwtkeys"
+	"github.com/akshaysangma/go-serve/internal/common/metrics"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
 const (
-	AuthContextKey contextKey = "authtoken"
-	authHeader     string     = "Authorization"
+	authHeader   string = "Authorization"
+	apiKeyHeader string = "X-API-Key"
 )
 
 // Claims struct that extends jwt.RegisteredClaims
 type AuthClaims struct {
-	UserID   string `json:"user_id"`
-	Email    string `json:"email"`
-	Username string `json:"username"`
+	UserID   string   `json:"user_id"`
+	Email    string   `json:"email"`
+	Username string   `json:"username"`
+	Roles    []string `json:"roles,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
+	Plan     string   `json:"plan,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func AuthMiddleware(jwtSecret []byte, defaultLogger *zap.Logger) Middleware {
+// RevocationChecker reports whether an access token has been revoked before its expiry.
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, jti string, expiresAt time.Time) (bool, error)
+}
+
+// APIKeyAuthenticator resolves an API key to the principal it acts as.
+// Invalid keys are reported with an error wrapping auth.ErrUnauthenticated.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*auth.Principal, error)
+}
+
+// AuthMiddleware authenticates the request with the X-API-Key header when it
+// is present and with the bearer token otherwise. Bearer tokens are validated
+// against the key named by their kid header and rejected if their jti has
+// been revoked.
+func AuthMiddleware(keys *jwtkeys.Keyring, revocations RevocationChecker, apiKeys APIKeyAuthenticator, defaultLogger *zap.Logger) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r, next, span := startMiddlewareSpan(r, "AuthMiddleware", next)
+			defer span.End()
 			logger := LoggerFromContext(r.Context(), defaultLogger)
+
+			if apiKey := r.Header.Get(apiKeyHeader); apiKey != "" {
+				principal, err := apiKeys.AuthenticateAPIKey(r.Context(), apiKey)
+				if err != nil {
+					if errors.Is(err, auth.ErrUnauthenticated) {
+						logger.Warn("Invalid API key received", zap.Error(err))
+						rejectUnauthenticated(w, r, "invalid_api_key", "invalid or expired API key")
+						return
+					}
+					logger.Error("API key authentication failed", zap.Error(err))
+					apierror.Write(w, r, apierror.Internal(err))
+					return
+				}
+
+				AddLoggerFields(r.Context(), zap.String("user_id", principal.UserID.String()), zap.String("api_key_id", principal.TokenID))
+				next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+				return
+			}
+
 			token := GetTokenFromHeader(r)
 			if token == "" {
 				logger.Error("Invalid auth token received")
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				rejectUnauthenticated(w, r, "missing_credentials", "missing bearer token or API key")
 				return
 			}
 
-			jwtToken, err := jwt.ParseWithClaims(token, &AuthClaims{}, func(t *jwt.Token) (interface{}, error) {
-				if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-				}
-				return jwtSecret, nil
-			})
+			jwtToken, err := jwt.ParseWithClaims(token, &AuthClaims{}, keys.Keyfunc)
 			if err != nil {
 				logger.Error("Token validation error", zap.Error(err))
-				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+				rejectUnauthenticated(w, r, "invalid_token", "invalid or expired token")
 				return
 			}
 
 			if claims, ok := jwtToken.Claims.(*AuthClaims); ok && jwtToken.Valid {
-				reqWithAuth := r.WithContext(context.WithValue(r.Context(), AuthContextKey, claims.ID))
-				next.ServeHTTP(w, reqWithAuth)
+				if claims.ID == "" || claims.ExpiresAt == nil {
+					logger.Error("Auth token without jti or expiry received")
+					rejectUnauthenticated(w, r, "invalid_token", "invalid or expired token")
+					return
+				}
+
+				revoked, err := revocations.IsRevoked(r.Context(), claims.ID, claims.ExpiresAt.Time)
+				if err != nil {
+					logger.Error("Token revocation check failed", zap.Error(err))
+					apierror.Write(w, r, apierror.Internal(err))
+					return
+				}
+				if revoked {
+					logger.Warn("Revoked auth token received", zap.String("jti", claims.ID))
+					rejectUnauthenticated(w, r, "revoked_token", "invalid or expired token")
+					return
+				}
+
+				userID, err := uuid.Parse(claims.UserID)
+				if err != nil {
+					logger.Error("Auth token with invalid user ID received", zap.Error(err))
+					rejectUnauthenticated(w, r, "invalid_token", "invalid or expired token")
+					return
+				}
+
+				principal := &auth.Principal{
+					Method:    auth.MethodJWT,
+					UserID:    userID,
+					Email:     claims.Email,
+					Username:  claims.Username,
+					Roles:     claims.Roles,
+					Scopes:    claims.Scopes,
+					Plan:      claims.Plan,
+					TokenID:   claims.ID,
+					ExpiresAt: claims.ExpiresAt.Time,
+				}
+				AddLoggerFields(r.Context(), zap.String("user_id", claims.UserID))
+				next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
 			} else {
 				logger.Error("Invalid or expired auth token received")
-				http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+				rejectUnauthenticated(w, r, "invalid_token", "invalid or expired token")
 			}
 
 		})
 	}
 }
 
+// rejectUnauthenticated counts the failure under reason and writes a 401.
+func rejectUnauthenticated(w http.ResponseWriter, r *http.Request, reason, detail string) {
+	metrics.AuthFailed(reason)
+	apierror.Write(w, r, apierror.Unauthorized(detail))
+}
+
 func GetTokenFromHeader(r *http.Request) string {
 	token := r.Header.Get(authHeader)
 	if len(token) > 7 && token[:7] == "Bearer " {
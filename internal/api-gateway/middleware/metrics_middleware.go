@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/akshaysangma/go-serve/internal/common/metrics"
+)
+
+type metricsRouteKey struct{}
+
+// MetricsMiddleware records the count and latency of requests by the route
+// pattern they matched, their method and their status code.
+//
+// Wrapped around the whole router it counts every request, including ones no
+// route matched, which are labelled "unmatched". Added again to the chain of
+// a route it only reports that route's pattern to the outer one. Patterns are
+// those of the mux that served the route, so routes behind
+// http.StripPrefix("/v1", ...) are labelled without the /v1 prefix.
+//
+// A request whose handler panics is recorded as a 500, since
+// RecoveryMiddleware turns the panic into one.
+func MetricsMiddleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if route, ok := r.Context().Value(metricsRouteKey{}).(*string); ok {
+				*route = r.Pattern
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			ww := &wrapperResponseWriter{ResponseWriter: w}
+			route := r.Pattern
+			r = r.WithContext(context.WithValue(r.Context(), metricsRouteKey{}, &route))
+			completed := false
+
+			defer func() {
+				status := ww.status()
+				if !completed {
+					status = http.StatusInternalServerError
+				}
+				if route == "" {
+					route = "unmatched"
+				}
+				metrics.ObserveHTTPRequest(route, r.Method, status, time.Since(start))
+			}()
+
+			next.ServeHTTP(ww, r)
+			completed = true
+		})
+	}
+}
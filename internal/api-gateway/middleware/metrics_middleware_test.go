@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/akshaysangma/go-serve/internal/common/metrics"
+	"go.uber.org/zap"
+)
+
+// requestCount reads goserve_http_requests_total for the given labels.
+func requestCount(t *testing.T, route, method, status string) float64 {
+	t.Helper()
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"route": route, "method": method, "status": status}
+	for _, family := range families {
+		if family.GetName() != "goserve_http_requests_total" {
+			continue
+		}
+	metrics:
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if want[label.GetName()] != label.GetValue() {
+					continue metrics
+				}
+			}
+			return m.GetCounter().GetValue()
+		}
+	}
+	return 0
+}
+
+// TestMetricsMiddlewareCountsEveryRequest builds the router layout of main and
+// checks that panics, unmatched paths and routes behind StripPrefix are all
+// counted under the expected labels.
+func TestMetricsMiddlewareCountsEveryRequest(t *testing.T) {
+	metricsMiddleware := MetricsMiddleware()
+	router := http.NewServeMux()
+	router.Handle("GET /livez", metricsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	v1 := http.NewServeMux()
+	router.Handle("/v1/", http.StripPrefix("/v1", v1))
+	v1.Handle("GET /panic/{id}", metricsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+	handler := ChainMiddleware(RecoveryMiddleware(zap.NewNop()), MetricsMiddleware())(router)
+
+	tests := []struct {
+		path   string
+		route  string
+		status string
+	}{
+		{"/livez", "GET /livez", "200"},
+		{"/v1/panic/1", "GET /panic/{id}", "500"},
+		{"/nowhere", "unmatched", "404"},
+		{"/v1/nowhere", "unmatched", "404"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			before := requestCount(t, tt.route, http.MethodGet, tt.status)
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+			after := requestCount(t, tt.route, http.MethodGet, tt.status)
+			if after-before != 1 {
+				t.Errorf("requests{route=%q, status=%s} grew by %v, want 1", tt.route, tt.status, after-before)
+			}
+		})
+	}
+}
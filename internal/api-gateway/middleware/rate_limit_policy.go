@@ -0,0 +1,271 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/akshaysangma/go-serve/internal/common/config"
+	"github.com/akshaysangma/go-serve/internal/common/ratelimit"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// Rate limit backends accepted in RateLimitConfig.Backend.
+const (
+	RateLimitBackendMemory = "memory"
+	RateLimitBackendRedis  = "redis"
+)
+
+// Rate limit key strategies accepted in RateLimitConfig.KeyBy.
+const (
+	RateLimitKeyByIP     = "ip"
+	RateLimitKeyByUser   = "user"
+	RateLimitKeyByAPIKey = "api_key"
+)
+
+// DefaultRateLimitPolicy is the policy built from the top-level rate limit
+// settings. Route groups using a policy that is not configured use it too.
+const DefaultRateLimitPolicy = "default"
+
+// ClientIPRateLimitPolicy limits every authenticated route per client IP
+// before credentials are checked. It is off unless configured.
+const ClientIPRateLimitPolicy = "client_ip"
+
+const (
+	defaultRateLimitMaxClients  = 10000
+	defaultRateLimitIdleTimeout = 10 * time.Minute
+	// rateLimitRedisRetryInterval is how long Redis is bypassed after a failure.
+	rateLimitRedisRetryInterval = 5 * time.Second
+	rateLimitRedisKeyPrefix     = "goserve:ratelimit:"
+	rateLimitRedisQuotaPrefix   = "goserve:quota:"
+)
+
+// RateLimitPolicies holds the limiters and quota counters of every
+// configured policy. Policies can be replaced with Reload while requests
+// are served.
+type RateLimitPolicies struct {
+	newLimiter func(policy string, interval time.Duration, burst int) ratelimit.RateLimiter
+	quotas     ratelimit.QuotaCounter
+	current    atomic.Pointer[rateLimitPolicySet]
+}
+
+// rateLimitPolicySet is one generation of policies, swapped as a whole on
+// reload so a request never sees a mix of old and new settings.
+type rateLimitPolicySet struct {
+	policies         map[string]*rateLimitPolicy
+	routes           map[string]string
+	clientIPHeader   string
+	trustedProxyHops int
+	// limiters holds every limiter of the set by the name it was built for,
+	// so a reload can keep those whose settings did not change.
+	limiters map[string]rateLimiterEntry
+}
+
+type rateLimiterEntry struct {
+	interval time.Duration
+	burst    int
+	limiter  ratelimit.RateLimiter
+}
+
+type rateLimitPolicy struct {
+	name  string
+	keyBy string
+	tier  *rateLimitTier
+	plans map[string]*rateLimitTier
+}
+
+// rateLimitTier is a policy as it applies to one plan.
+type rateLimitTier struct {
+	limiter      ratelimit.RateLimiter
+	dailyQuota   int64
+	monthlyQuota int64
+}
+
+// forPlan returns the tier for plan, or the policy's own tier when the plan
+// has no override.
+func (p *rateLimitPolicy) forPlan(plan string) *rateLimitTier {
+	if tier, ok := p.plans[plan]; ok {
+		return tier
+	}
+	return p.tier
+}
+
+// NewRateLimitPolicies builds the policies in config on the backend selected
+// by cfg.Backend. redisClient may be nil when the backend is memory.
+func NewRateLimitPolicies(cfg config.RateLimitConfig, redisClient redis.Scripter, logger *zap.Logger) (*RateLimitPolicies, error) {
+	var newLimiter func(policy string, interval time.Duration, burst int) ratelimit.RateLimiter
+	var quotas ratelimit.QuotaCounter
+
+	maxClients := cfg.MaxClients
+	if maxClients <= 0 {
+		maxClients = defaultRateLimitMaxClients
+	}
+	idleTimeout := cfg.IdleTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = defaultRateLimitIdleTimeout
+	}
+	newMemory := func(interval time.Duration, burst int) ratelimit.RateLimiter {
+		return ratelimit.NewMemory(interval, burst, maxClients, idleTimeout)
+	}
+
+	switch cfg.Backend {
+	case "", RateLimitBackendMemory:
+		newLimiter = func(_ string, interval time.Duration, burst int) ratelimit.RateLimiter {
+			return newMemory(interval, burst)
+		}
+		quotas = ratelimit.NewMemoryQuota()
+	case RateLimitBackendRedis:
+		if redisClient == nil {
+			return nil, fmt.Errorf("rate limit backend %q needs a Redis client", cfg.Backend)
+		}
+		breaker := ratelimit.NewBreaker(rateLimitRedisRetryInterval, logger)
+		newLimiter = func(policy string, interval time.Duration, burst int) ratelimit.RateLimiter {
+			primary := ratelimit.NewRedis(redisClient, rateLimitRedisKeyPrefix+policy+":", interval, burst)
+			return ratelimit.NewFallback(primary, newMemory(interval, burst), breaker)
+		}
+		quotas = ratelimit.NewQuotaFallback(ratelimit.NewRedisQuota(redisClient, rateLimitRedisQuotaPrefix), ratelimit.NewMemoryQuota(), breaker)
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q", cfg.Backend)
+	}
+
+	p := &RateLimitPolicies{
+		newLimiter: newLimiter,
+		quotas:     quotas,
+	}
+	if err := p.Reload(cfg); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Reload replaces the policies, routes and client IP settings with those in
+// cfg, keeping the current ones if cfg is invalid. The backend and the
+// in-memory client limits are fixed at creation and ignored. A policy or
+// plan whose interval and burst are unchanged keeps its limiter and with it
+// the burst buckets of its clients; only changed ones start full. Quota
+// counts always carry over.
+func (p *RateLimitPolicies) Reload(cfg config.RateLimitConfig) error {
+	var previous map[string]rateLimiterEntry
+	if set := p.current.Load(); set != nil {
+		previous = set.limiters
+	}
+	limiters := make(map[string]rateLimiterEntry)
+	limiter := func(name string, interval time.Duration, burst int) ratelimit.RateLimiter {
+		entry, ok := previous[name]
+		if !ok || entry.interval != interval || entry.burst != burst {
+			entry = rateLimiterEntry{interval: interval, burst: burst, limiter: p.newLimiter(name, interval, burst)}
+		}
+		limiters[name] = entry
+		return entry.limiter
+	}
+
+	base := config.RateLimitPolicyConfig{
+		LimitInterval: cfg.LimitInterval,
+		Burst:         cfg.Burst,
+		KeyBy:         cfg.KeyBy,
+	}
+	if override, ok := cfg.Policies[DefaultRateLimitPolicy]; ok {
+		base = mergeRateLimitPolicy(base, override)
+	}
+
+	policyConfigs := map[string]config.RateLimitPolicyConfig{DefaultRateLimitPolicy: base}
+	for name, policy := range cfg.Policies {
+		if name != DefaultRateLimitPolicy {
+			policyConfigs[name] = mergeRateLimitPolicy(base, policy)
+		}
+	}
+
+	policies := make(map[string]*rateLimitPolicy, len(policyConfigs))
+	for name, pc := range policyConfigs {
+		switch pc.KeyBy {
+		case "", RateLimitKeyByIP, RateLimitKeyByUser, RateLimitKeyByAPIKey:
+		default:
+			return fmt.Errorf("rate limit policy %q: unknown key strategy %q", name, pc.KeyBy)
+		}
+		if pc.LimitInterval <= 0 {
+			return fmt.Errorf("rate limit policy %q: limit interval must be positive", name)
+		}
+
+		policy := &rateLimitPolicy{
+			name:  name,
+			keyBy: pc.KeyBy,
+			tier: &rateLimitTier{
+				limiter:      limiter(name, pc.LimitInterval, pc.Burst),
+				dailyQuota:   pc.DailyQuota,
+				monthlyQuota: pc.MonthlyQuota,
+			},
+			plans: make(map[string]*rateLimitTier, len(pc.Plans)),
+		}
+		for plan, override := range pc.Plans {
+			tier := mergeRateLimitPolicy(pc, config.RateLimitPolicyConfig{
+				LimitInterval: override.LimitInterval,
+				Burst:         override.Burst,
+				DailyQuota:    override.DailyQuota,
+				MonthlyQuota:  override.MonthlyQuota,
+			})
+			policy.plans[plan] = &rateLimitTier{
+				limiter:      limiter(name+":"+plan, tier.LimitInterval, tier.Burst),
+				dailyQuota:   tier.DailyQuota,
+				monthlyQuota: tier.MonthlyQuota,
+			}
+		}
+		policies[name] = policy
+	}
+
+	routes := make(map[string]string, len(cfg.Routes))
+	for pattern, name := range cfg.Routes {
+		if _, ok := policies[name]; !ok {
+			return fmt.Errorf("rate limit route %q uses unknown policy %q", pattern, name)
+		}
+		routes[strings.ToLower(pattern)] = name
+	}
+
+	p.current.Store(&rateLimitPolicySet{
+		policies:         policies,
+		routes:           routes,
+		clientIPHeader:   cfg.ClientIPHeader,
+		trustedProxyHops: cfg.TrustedProxyHops,
+		limiters:         limiters,
+	})
+	return nil
+}
+
+// load returns the policies in effect.
+func (p *RateLimitPolicies) load() *rateLimitPolicySet {
+	return p.current.Load()
+}
+
+// policy returns the policy configured for the route pattern, falling back
+// to the named group policy and then to the default policy.
+func (p *rateLimitPolicySet) policy(pattern, group string) *rateLimitPolicy {
+	if name, ok := p.routes[strings.ToLower(pattern)]; ok {
+		return p.policies[name]
+	}
+	if policy, ok := p.policies[group]; ok {
+		return policy
+	}
+	return p.policies[DefaultRateLimitPolicy]
+}
+
+// mergeRateLimitPolicy returns override with its zero fields taken from base.
+// Plans are not inherited since plan overrides are relative to one policy.
+func mergeRateLimitPolicy(base, override config.RateLimitPolicyConfig) config.RateLimitPolicyConfig {
+	if override.LimitInterval == 0 {
+		override.LimitInterval = base.LimitInterval
+	}
+	if override.Burst == 0 {
+		override.Burst = base.Burst
+	}
+	if override.KeyBy == "" {
+		override.KeyBy = base.KeyBy
+	}
+	if override.DailyQuota == 0 {
+		override.DailyQuota = base.DailyQuota
+	}
+	if override.MonthlyQuota == 0 {
+		override.MonthlyQuota = base.MonthlyQuota
+	}
+	return override
+}
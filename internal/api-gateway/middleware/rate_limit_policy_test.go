@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/akshaysangma/go-serve/internal/common/config"
+	"go.uber.org/zap"
+)
+
+// TestReloadKeepsUnchangedLimiters checks that a reload only resets the burst
+// buckets of policies whose interval or burst changed.
+func TestReloadKeepsUnchangedLimiters(t *testing.T) {
+	cfg := config.RateLimitConfig{
+		Backend:       RateLimitBackendMemory,
+		LimitInterval: time.Minute,
+		Burst:         1,
+		KeyBy:         RateLimitKeyByIP,
+		Policies: map[string]config.RateLimitPolicyConfig{
+			"auth": {Burst: 1},
+		},
+	}
+	policies, err := NewRateLimitPolicies(cfg, nil, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	allowed := func(policy string) bool {
+		t.Helper()
+		result, err := policies.load().policies[policy].tier.limiter.Allow(context.Background(), "ip:192.0.2.1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result.Allowed
+	}
+	allowed(DefaultRateLimitPolicy)
+	allowed("auth")
+
+	cfg.Routes = map[string]string{"POST /auth/login": "auth"}
+	cfg.Policies = map[string]config.RateLimitPolicyConfig{"auth": {Burst: 2}}
+	if err := policies.Reload(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if allowed(DefaultRateLimitPolicy) {
+		t.Error("unchanged default policy started with a full bucket")
+	}
+	if !allowed("auth") {
+		t.Error("auth policy with a new burst kept its old bucket")
+	}
+}
@@ -1,27 +1,173 @@
 package middleware
 
 import (
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/akshaysangma/go-serve/internal/common/config"
+	"github.com/akshaysangma/go-serve/internal/api-gateway/apierror"
+	"github.com/akshaysangma/go-serve/internal/api-gateway/auth"
+	"github.com/akshaysangma/go-serve/internal/common/metrics"
+	"github.com/akshaysangma/go-serve/internal/common/ratelimit"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
-	"golang.org/x/time/rate"
 )
 
-// RateLimitMiddleware is a leaking bucket type Ratelimit
-func RateLimitMiddleware(config config.RateLimitConfig, defaultLogger *zap.Logger) Middleware {
+// RateLimitMiddleware applies the policy configured for the matched route, or
+// the group policy otherwise, at the tier of the caller's plan. A request
+// takes a token from the caller's burst bucket and then counts against the
+// policy's daily and monthly quotas.
+// Keying by user or API key needs a principal, so it must run after
+// AuthMiddleware; unauthenticated callers are keyed by IP. Requests are let
+// through if the limiter fails, since an outage of the limiter should not
+// take the API down with it.
+func RateLimitMiddleware(policies *RateLimitPolicies, group string, defaultLogger *zap.Logger) Middleware {
 	return func(next http.Handler) http.Handler {
-		rl := rate.NewLimiter(rate.Every(config.LimitInterval), config.Burst)
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			logger := LoggerFromContext(r.Context(), defaultLogger)
-			if rl.Allow() {
-				logger.Info("Rate limited API called", zap.Float64("tokens_available", rl.Tokens()))
+			r, next, span := startMiddlewareSpan(r, "RateLimitMiddleware", next)
+			defer span.End()
+			set := policies.load()
+			policy := set.policy(r.Pattern, group)
+			span.SetAttributes(attribute.String("rate_limit.policy", policy.name))
+
+			key := rateLimitKey(r, policy.keyBy, set.clientIPHeader, set.trustedProxyHops)
+			enforceRateLimit(w, r, next, policies.quotas, policy, key, defaultLogger)
+		})
+	}
+}
+
+// ClientIPRateLimitMiddleware applies the named policy per client IP. It runs
+// in front of AuthMiddleware so callers presenting bad credentials are
+// throttled before each attempt costs a database lookup. Requests pass
+// unlimited if the policy is not configured.
+func ClientIPRateLimitMiddleware(policies *RateLimitPolicies, name string, defaultLogger *zap.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			set := policies.load()
+			policy, ok := set.policies[name]
+			if !ok {
 				next.ServeHTTP(w, r)
 				return
 			}
-			logger.Error("Rate limit reached")
-			w.Header().Add("Retry-After", config.LimitInterval.String())
-			http.Error(w, "Rate limit reached. Please try after sometime.", http.StatusTooManyRequests)
+			r, next, span := startMiddlewareSpan(r, "ClientIPRateLimitMiddleware", next)
+			defer span.End()
+			span.SetAttributes(attribute.String("rate_limit.policy", policy.name))
+
+			key := "ip:" + ClientIP(r, set.clientIPHeader, set.trustedProxyHops)
+			enforceRateLimit(w, r, next, policies.quotas, policy, key, defaultLogger)
 		})
 	}
 }
+
+// enforceRateLimit serves r with next unless key is over the burst or a
+// quota of policy, at the tier of the caller's plan.
+func enforceRateLimit(w http.ResponseWriter, r *http.Request, next http.Handler, quotaCounter ratelimit.QuotaCounter, policy *rateLimitPolicy, key string, defaultLogger *zap.Logger) {
+	var plan string
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+		plan = principal.Plan
+	}
+	tier := policy.forPlan(plan)
+
+	logger := LoggerFromContext(r.Context(), defaultLogger).With(zap.String("rate_limit_policy", policy.name), zap.String("rate_limit_key", key))
+
+	result, err := tier.limiter.Allow(r.Context(), key)
+	if err != nil {
+		logger.Error("Rate limiter failed, allowing request", zap.Error(err))
+		next.ServeHTTP(w, r)
+		return
+	}
+	setRateLimitHeaders(w, result)
+	if !result.Allowed {
+		logger.Error("Rate limit reached")
+		metrics.RateLimitRejected(policy.name, "burst")
+		apierror.Write(w, r, apierror.RateLimited("Rate limit reached. Please try after sometime.", result.RetryAfter))
+		return
+	}
+
+	quotas := []struct {
+		period ratelimit.Period
+		limit  int64
+	}{
+		{ratelimit.PeriodDay, tier.dailyQuota},
+		{ratelimit.PeriodMonth, tier.monthlyQuota},
+	}
+	for _, quota := range quotas {
+		if quota.limit <= 0 {
+			continue
+		}
+		used, resetAt, err := quotaCounter.Increment(r.Context(), policy.name+":"+key, quota.period)
+		if err != nil {
+			logger.Error("Quota counter failed, allowing request", zap.Error(err))
+			continue
+		}
+		if used > quota.limit {
+			logger.Error("Quota exhausted", zap.String("period", string(quota.period)), zap.Int64("quota", quota.limit))
+			metrics.RateLimitRejected(policy.name, string(quota.period))
+			resetAfter := time.Until(resetAt)
+			setRateLimitHeaders(w, ratelimit.Result{Limit: int(quota.limit), ResetAfter: resetAfter})
+			apierror.Write(w, r, apierror.RateLimited("The "+string(quota.period)+"ly quota for this API is used up.", resetAfter))
+			return
+		}
+	}
+
+	logger.Info("Rate limited API called", zap.Int("tokens_available", result.Remaining))
+	next.ServeHTTP(w, r)
+}
+
+// setRateLimitHeaders sets the RateLimit-* headers of the IETF
+// draft-ietf-httpapi-ratelimit-headers. Reset is in seconds until the
+// bucket is full again.
+func setRateLimitHeaders(w http.ResponseWriter, result ratelimit.Result) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+}
+
+// ceilSeconds rounds d up to whole seconds.
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// rateLimitKey identifies the client of r according to keyBy. Keys are
+// prefixed with their kind so a user ID can never collide with an IP.
+func rateLimitKey(r *http.Request, keyBy, clientIPHeader string, trustedProxyHops int) string {
+	if keyBy != RateLimitKeyByIP {
+		if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+			if keyBy == RateLimitKeyByAPIKey && principal.Method == auth.MethodAPIKey {
+				return "api_key:" + principal.TokenID
+			}
+			return "user:" + principal.UserID.String()
+		}
+	}
+	return "ip:" + ClientIP(r, clientIPHeader, trustedProxyHops)
+}
+
+// ClientIP returns the address of the client. When header is set (for example
+// X-Forwarded-For behind proxies that append to it) the entry added by the
+// outermost of trustedProxyHops proxies is used, counting from the right.
+// Entries further left come from the client and are ignored. If the header
+// has fewer entries, the leftmost is used, since every entry was then added
+// by a trusted proxy.
+func ClientIP(r *http.Request, header string, trustedProxyHops int) string {
+	if header != "" && trustedProxyHops > 0 {
+		var entries []string
+		for _, value := range r.Header.Values(header) {
+			for _, entry := range strings.Split(value, ",") {
+				if entry = strings.TrimSpace(entry); entry != "" {
+					entries = append(entries, entry)
+				}
+			}
+		}
+		if len(entries) > 0 {
+			return entries[max(len(entries)-trustedProxyHops, 0)]
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIPIgnoresForgedForwardedEntries(t *testing.T) {
+	tests := []struct {
+		name      string
+		forwarded []string
+		hops      int
+		want      string
+	}{
+		{"no header", nil, 1, "192.0.2.1"},
+		{"one proxy", []string{"198.51.100.7"}, 1, "198.51.100.7"},
+		{"forged entry", []string{"203.0.113.9, 198.51.100.7"}, 1, "198.51.100.7"},
+		{"two proxies", []string{"203.0.113.9, 198.51.100.7, 10.0.0.2"}, 2, "198.51.100.7"},
+		{"fewer entries than hops", []string{"198.51.100.7"}, 2, "198.51.100.7"},
+		{"repeated header", []string{"203.0.113.9", "198.51.100.7"}, 1, "198.51.100.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = "192.0.2.1:4711"
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if got := ClientIP(r, "X-Forwarded-For", tt.hops); got != tt.want {
+				t.Errorf("ClientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/akshaysangma/go-serve/internal/api-gateway/apierror"
+	"github.com/akshaysangma/go-serve/internal/api-gateway/auth"
+	"go.uber.org/zap"
+)
+
+// RequireRole allows the request if the principal has at least one of roles.
+// It must run after AuthMiddleware.
+func RequireRole(defaultLogger *zap.Logger, roles ...string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r, next, span := startMiddlewareSpan(r, "RequireRole", next)
+			defer span.End()
+			logger := LoggerFromContext(r.Context(), defaultLogger)
+			principal, ok := auth.PrincipalFromContext(r.Context())
+			if !ok {
+				logger.Error("Role check without authenticated principal")
+				apierror.Write(w, r, apierror.Unauthorized("authentication required"))
+				return
+			}
+
+			for _, role := range roles {
+				if principal.HasRole(role) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			logger.Warn("Missing required role", zap.Strings("required_roles", roles), zap.Strings("roles", principal.Roles))
+			apierror.Write(w, r, apierror.Forbidden("missing required role"))
+		})
+	}
+}
+
+// RequireScope allows the request only if the principal was granted every one
+// of scopes. Scopes narrow what an API key may do on behalf of its owner, so
+// access tokens, which act with every permission of their user, are not
+// checked. It must run after AuthMiddleware.
+func RequireScope(defaultLogger *zap.Logger, scopes ...string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r, next, span := startMiddlewareSpan(r, "RequireScope", next)
+			defer span.End()
+			logger := LoggerFromContext(r.Context(), defaultLogger)
+			principal, ok := auth.PrincipalFromContext(r.Context())
+			if !ok {
+				logger.Error("Scope check without authenticated principal")
+				apierror.Write(w, r, apierror.Unauthorized("authentication required"))
+				return
+			}
+
+			if principal.Method != auth.MethodAPIKey {
+				next.ServeHTTP(w, r)
+				return
+			}
+			for _, scope := range scopes {
+				if !principal.HasScope(scope) {
+					logger.Warn("Missing required scope", zap.String("required_scope", scope), zap.Strings("scopes", principal.Scopes))
+					apierror.Write(w, r, apierror.Forbidden("missing required scope "+scope))
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/akshaysangma/go-serve/internal/api-gateway/auth"
+	"github.com/akshaysangma/go-serve/internal/common/config"
+	"github.com/akshaysangma/go-serve/internal/common/jwtkeys"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type stubAPIKeys struct {
+	principal *auth.Principal
+}
+
+func (s stubAPIKeys) AuthenticateAPIKey(context.Context, string) (*auth.Principal, error) {
+	return s.principal, nil
+}
+
+type notRevoked struct{}
+
+func (notRevoked) IsRevoked(context.Context, string, time.Time) (bool, error) {
+	return false, nil
+}
+
+// TestAdminRouteRequiresScope builds the admin route chain used in main and
+// checks that an API key of an admin only passes with the admin scope.
+func TestAdminRouteRequiresScope(t *testing.T) {
+	logger := zap.NewNop()
+	keys, err := jwtkeys.NewKeyring(config.JWTConfig{
+		Algorithm: "HS256",
+		KeyID:     "test",
+		Secret:    "0123456789abcdef0123456789abcdef",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID := uuid.New()
+	accessToken, err := keys.Sign(AuthClaims{
+		UserID: userID.String(),
+		Roles:  []string{auth.RoleAdmin},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		header     string
+		value      string
+		scopes     []string
+		wantStatus int
+	}{
+		{"api key without scopes", apiKeyHeader, "gsk_test", []string{}, http.StatusForbidden},
+		{"api key with other scope", apiKeyHeader, "gsk_test", []string{auth.ScopeUsersRead}, http.StatusForbidden},
+		{"api key with admin scope", apiKeyHeader, "gsk_test", []string{auth.ScopeAdmin}, http.StatusOK},
+		{"access token", authHeader, "Bearer " + accessToken, nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiKeys := stubAPIKeys{principal: &auth.Principal{
+				Method: auth.MethodAPIKey,
+				UserID: userID,
+				Roles:  []string{auth.RoleAdmin},
+				Scopes: tt.scopes,
+			}}
+			chain := ChainMiddleware(
+				AuthMiddleware(keys, notRevoked{}, apiKeys, logger),
+				RequireRole(logger, auth.RoleAdmin),
+				RequireScope(logger, auth.ScopeAdmin),
+			)
+			handler := chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			req.Header.Set(tt.header, tt.value)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/akshaysangma/go-serve/internal/api-gateway/apierror"
+	"go.uber.org/zap"
+)
+
+// recoveryResponseWriter records whether the response has started, since a
+// status can no longer be sent after that.
+type recoveryResponseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (rw *recoveryResponseWriter) WriteHeader(statusCode int) {
+	rw.wroteHeader = true
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rw *recoveryResponseWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	return rw.ResponseWriter.Write(b)
+}
+
+// RecoveryMiddleware turns a panic in a handler into a logged 500 response.
+// It must be the outermost middleware. It installs the request logger holder
+// that RequestLoggerMiddleware fills in, so the panic is logged with the
+// request ID and any fields added further down the chain.
+// http.ErrAbortHandler is re-panicked so net/http can abort the response.
+func RecoveryMiddleware(defaultLogger *zap.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rl := &requestLogger{logger: defaultLogger}
+			rw := &recoveryResponseWriter{ResponseWriter: w}
+
+			defer func() {
+				p := recover()
+				if p == nil {
+					return
+				}
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+
+				rl.logger.Error("Recovered from panic", zap.Any("panic", p), zap.Stack("stack"))
+				if rw.wroteHeader {
+					return
+				}
+				apierror.Write(w, r, apierror.Internal(fmt.Errorf("panic: %v", p)))
+			}()
+
+			ctx := context.WithValue(r.Context(), RequestLoggerKey, rl)
+			next.ServeHTTP(rw, r.WithContext(ctx))
+		})
+	}
+}
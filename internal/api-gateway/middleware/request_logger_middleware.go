@@ -5,7 +5,9 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/akshaysangma/go-serve/internal/api-gateway/apierror"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -13,6 +15,13 @@ const (
 	RequestLoggerKey contextKey = "requestLogger"
 )
 
+// requestLogger is stored in the request context so middleware further down
+// the chain can enrich the logger used for the final "Request Completed" entry.
+type requestLogger struct {
+	logger    *zap.Logger
+	requestID string
+}
+
 type wrapperResponseWriter struct {
 	http.ResponseWriter
 	statusCode int
@@ -23,28 +32,54 @@ func (ww *wrapperResponseWriter) WriteHeader(statusCode int) {
 	ww.statusCode = statusCode
 }
 
+// status returns the status code sent, which is 200 when the handler wrote
+// the body without calling WriteHeader.
+func (ww *wrapperResponseWriter) status() int {
+	if ww.statusCode == 0 {
+		return http.StatusOK
+	}
+	return ww.statusCode
+}
+
 func RequestLoggerMiddleware(logger *zap.Logger) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
 			ww := &wrapperResponseWriter{ResponseWriter: w}
+			// Logs carry the server span rather than this middleware's own.
+			serverSpan := trace.SpanContextFromContext(r.Context())
+			r, next, span := startMiddlewareSpan(r, "RequestLoggerMiddleware", next)
+			defer span.End()
 
-			requestID := r.Header.Get("X-Request-ID")
+			requestID := r.Header.Get(apierror.RequestIDHeader)
 			if requestID == "" {
 				requestID = uuid.New().String()
 			}
+			w.Header().Set(apierror.RequestIDHeader, requestID)
 
-			requestLogger := logger.With(zap.String("request_id", requestID),
+			// Reuse the holder installed by RecoveryMiddleware so a panic is
+			// logged with the request fields.
+			ctx := r.Context()
+			rl, ok := ctx.Value(RequestLoggerKey).(*requestLogger)
+			if !ok {
+				rl = &requestLogger{}
+				ctx = context.WithValue(ctx, RequestLoggerKey, rl)
+			}
+			rl.requestID = requestID
+			rl.logger = logger.With(zap.String("request_id", requestID),
 				zap.String("method", r.Method),
 				zap.String("path", r.URL.Path),
 				zap.String("remote_ip", r.RemoteAddr),
 			)
+			// TracingMiddleware runs first, so the request's span is known here.
+			if serverSpan.IsValid() {
+				rl.logger = rl.logger.With(zap.String("trace_id", serverSpan.TraceID().String()), zap.String("span_id", serverSpan.SpanID().String()))
+			}
 
-			ctx := context.WithValue(r.Context(), RequestLoggerKey, requestLogger)
 			next.ServeHTTP(ww, r.WithContext(ctx))
 
-			requestLogger.Info("Request Completed",
-				zap.Int("status_code", ww.statusCode),
+			rl.logger.Info("Request Completed",
+				zap.Int("status_code", ww.status()),
 				zap.Duration("duration", time.Since(start)))
 
 		})
@@ -52,10 +87,19 @@ func RequestLoggerMiddleware(logger *zap.Logger) Middleware {
 }
 
 func LoggerFromContext(ctx context.Context, defaultLogger *zap.Logger) *zap.Logger {
-	if logger, ok := ctx.Value(RequestLoggerKey).(*zap.Logger); ok {
-		return logger
+	if rl, ok := ctx.Value(RequestLoggerKey).(*requestLogger); ok {
+		return rl.logger
 	}
 
 	defaultLogger.Warn("Contextual logger not found, using default logger.")
 	return defaultLogger
 }
+
+// AddLoggerFields attaches fields to the request-scoped logger, including the
+// completion entry written by RequestLoggerMiddleware. It is a no-op outside
+// of RequestLoggerMiddleware.
+func AddLoggerFields(ctx context.Context, fields ...zap.Field) {
+	if rl, ok := ctx.Value(RequestLoggerKey).(*requestLogger); ok {
+		rl.logger = rl.logger.With(fields...)
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/akshaysangma/go-serve/internal/api-gateway/middleware"
+
+var tracer = otel.Tracer(instrumentationName)
+
+// TracingMiddleware continues the trace of an incoming W3C traceparent
+// header, or starts a new one, with a server span named after the matched
+// route. It must run before RequestLoggerMiddleware so that request logs
+// carry the trace ID.
+func TracingMiddleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+			name := r.Pattern
+			if name == "" {
+				name = r.Method
+			}
+			ctx, span := tracer.Start(ctx, name,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(r.Method),
+					semconv.HTTPRoute(r.Pattern),
+					semconv.URLPath(r.URL.Path),
+					semconv.UserAgentOriginal(r.UserAgent()),
+					semconv.ClientAddress(ClientIP(r, "", 0)),
+				),
+			)
+			defer span.End()
+
+			ww := &wrapperResponseWriter{ResponseWriter: w}
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			status := ww.status()
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+		})
+	}
+}
+
+// startMiddlewareSpan starts a span named name for the work a middleware
+// does before calling next. It returns r carrying the span and a next that
+// ends the span and restores the parent span, so spans of later handlers are
+// not nested under the middleware. Callers should also defer span.End() for
+// the paths that reject the request without calling next.
+func startMiddlewareSpan(r *http.Request, name string, next http.Handler) (*http.Request, http.Handler, trace.Span) {
+	parent := trace.SpanFromContext(r.Context())
+	ctx, span := tracer.Start(r.Context(), name)
+
+	return r.WithContext(ctx), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span.End()
+		next.ServeHTTP(w, r.WithContext(trace.ContextWithSpan(r.Context(), parent)))
+	}), span
+}
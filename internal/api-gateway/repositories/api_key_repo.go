@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	db "github.com/akshaysangma/go-serve/internal/database/postgres/sqlc"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type APIKey = db.APIKey
+
+type CreateAPIKeyParams struct {
+	UserID  uuid.UUID
+	Name    string
+	Prefix  string
+	KeyHash string
+	Scopes  []string
+	// ExpiresAt is nil for keys that never expire.
+	ExpiresAt *time.Time
+}
+
+// APIKeyRepository defines the interface for API key data operations.
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (APIKey, error)
+	GetAPIKeyByID(ctx context.Context, id uuid.UUID) (APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (APIKey, error)
+	ListAPIKeysByUserID(ctx context.Context, userID uuid.UUID) ([]APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) error
+	TouchAPIKeyLastUsed(ctx context.Context, id uuid.UUID) error
+}
+
+type postgresAPIKeyRepository struct {
+	queries *db.Queries
+}
+
+func NewAPIKeyRepository(queries *db.Queries) APIKeyRepository {
+	return &postgresAPIKeyRepository{
+		queries: queries,
+	}
+}
+
+func (r *postgresAPIKeyRepository) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (APIKey, error) {
+	var expiresAt pgtype.Timestamptz
+	if arg.ExpiresAt != nil {
+		expiresAt = pgtype.Timestamptz{Time: *arg.ExpiresAt, Valid: true}
+	}
+
+	key, err := r.queries.CreateAPIKey(ctx, db.CreateAPIKeyParams{
+		UserID:    arg.UserID,
+		Name:      arg.Name,
+		Prefix:    arg.Prefix,
+		KeyHash:   arg.KeyHash,
+		Scopes:    arg.Scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return APIKey{}, fmt.Errorf("repo: failed to create API key: %w", err)
+	}
+	return key, nil
+}
+
+func (r *postgresAPIKeyRepository) GetAPIKeyByID(ctx context.Context, id uuid.UUID) (APIKey, error) {
+	key, err := r.queries.GetAPIKeyByID(ctx, id)
+	if err != nil {
+		return APIKey{}, fmt.Errorf("repo: failed to get API key by ID: %w", err)
+	}
+	return key, nil
+}
+
+func (r *postgresAPIKeyRepository) GetAPIKeyByHash(ctx context.Context, keyHash string) (APIKey, error) {
+	key, err := r.queries.GetAPIKeyByHash(ctx, keyHash)
+	if err != nil {
+		return APIKey{}, fmt.Errorf("repo: failed to get API key by hash: %w", err)
+	}
+	return key, nil
+}
+
+func (r *postgresAPIKeyRepository) ListAPIKeysByUserID(ctx context.Context, userID uuid.UUID) ([]APIKey, error) {
+	keys, err := r.queries.ListAPIKeysByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("repo: failed to list API keys by user ID: %w", err)
+	}
+	return keys, nil
+}
+
+func (r *postgresAPIKeyRepository) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	err := r.queries.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return fmt.Errorf("repo: failed to revoke API key: %w", err)
+	}
+	return nil
+}
+
+func (r *postgresAPIKeyRepository) TouchAPIKeyLastUsed(ctx context.Context, id uuid.UUID) error {
+	err := r.queries.TouchAPIKeyLastUsed(ctx, id)
+	if err != nil {
+		return fmt.Errorf("repo: failed to update API key last used: %w", err)
+	}
+	return nil
+}
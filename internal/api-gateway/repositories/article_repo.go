@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"fmt"
+	"time"
 
 	db "github.com/akshaysangma/go-serve/internal/database/postgres/sqlc"
 	"github.com/google/uuid"
@@ -16,19 +17,33 @@ type CreateArticleParams struct {
 	AuthorID uuid.UUID
 }
 
+// UpdateArticleParams updates the article ID if AuthorID wrote it. Nil
+// fields keep their current value.
 type UpdateArticleParams struct {
-	ID      uuid.UUID
-	Title   string
-	Content string
+	ID       uuid.UUID
+	AuthorID uuid.UUID
+	Title    *string
+	Content  *string
+}
+
+// ListArticlesParams selects one page of articles. Nil filters match every
+// article and a nil After starts at the first page.
+type ListArticlesParams struct {
+	AuthorID      *uuid.UUID
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	After         *Cursor
+	Ascending     bool
+	Limit         int32
 }
 
 type ArticleRepository interface {
 	CreateArticle(ctx context.Context, arg CreateArticleParams) (Article, error)
 	GetArticleByID(ctx context.Context, id uuid.UUID) (Article, error)
-	ListArticles(ctx context.Context) ([]Article, error)
-	ListArticlesByAuthorID(ctx context.Context, authorID uuid.UUID) ([]Article, error) // If you have this query
+	ListArticles(ctx context.Context, arg ListArticlesParams) ([]Article, error)
 	UpdateArticle(ctx context.Context, arg UpdateArticleParams) (Article, error)
-	DeleteArticle(ctx context.Context, id uuid.UUID) error
+	// DeleteArticle reports false if no article id written by authorID exists.
+	DeleteArticle(ctx context.Context, id, authorID uuid.UUID) (bool, error)
 }
 
 type postgresArticleRepository struct {
@@ -62,27 +77,35 @@ func (r *postgresArticleRepository) GetArticleByID(ctx context.Context, id uuid.
 	return article, nil
 }
 
-func (r *postgresArticleRepository) ListArticles(ctx context.Context) ([]Article, error) {
-	articles, err := r.queries.ListArticles(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("repo: failed to list articles: %w", err)
+func (r *postgresArticleRepository) ListArticles(ctx context.Context, arg ListArticlesParams) ([]Article, error) {
+	cursorCreatedAt, cursorID := cursorArgs(arg.After, arg.Ascending)
+	params := db.ListArticlesAscendingParams{
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		AuthorID:        optionalUUID(arg.AuthorID),
+		CreatedAfter:    optionalTimestamptz(arg.CreatedAfter),
+		CreatedBefore:   optionalTimestamptz(arg.CreatedBefore),
+		PageLimit:       arg.Limit,
+	}
+	var articles []Article
+	var err error
+	if arg.Ascending {
+		articles, err = r.queries.ListArticlesAscending(ctx, params)
+	} else {
+		articles, err = r.queries.ListArticlesDescending(ctx, db.ListArticlesDescendingParams(params))
 	}
-	return articles, nil
-}
-
-func (r *postgresArticleRepository) ListArticlesByAuthorID(ctx context.Context, authorID uuid.UUID) ([]Article, error) {
-	articles, err := r.queries.ListArticlesByAuthorID(ctx, authorID)
 	if err != nil {
-		return nil, fmt.Errorf("repo: failed to list articles by author ID: %w", err)
+		return nil, fmt.Errorf("repo: failed to list articles: %w", err)
 	}
 	return articles, nil
 }
 
 func (r *postgresArticleRepository) UpdateArticle(ctx context.Context, arg UpdateArticleParams) (Article, error) {
 	article, err := r.queries.UpdateArticle(ctx, db.UpdateArticleParams{
-		ID:      arg.ID,
-		Title:   arg.Title,
-		Content: arg.Content,
+		ID:       arg.ID,
+		AuthorID: arg.AuthorID,
+		Title:    optionalText(arg.Title),
+		Content:  optionalText(arg.Content),
 	})
 	if err != nil {
 		return Article{}, fmt.Errorf("repo: failed to update article: %w", err)
@@ -90,10 +113,13 @@ func (r *postgresArticleRepository) UpdateArticle(ctx context.Context, arg Updat
 	return article, nil
 }
 
-func (r *postgresArticleRepository) DeleteArticle(ctx context.Context, id uuid.UUID) error {
-	err := r.queries.DeleteArticle(ctx, id)
+func (r *postgresArticleRepository) DeleteArticle(ctx context.Context, id, authorID uuid.UUID) (bool, error) {
+	rows, err := r.queries.DeleteArticle(ctx, db.DeleteArticleParams{
+		ID:       id,
+		AuthorID: authorID,
+	})
 	if err != nil {
-		return fmt.Errorf("repo: failed to delete article: %w", err)
+		return false, fmt.Errorf("repo: failed to delete article: %w", err)
 	}
-	return nil
+	return rows == 1, nil
 }
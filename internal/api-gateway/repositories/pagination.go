@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Cursor identifies the last row of a page in (created_at, id) order. The
+// next page starts strictly after it.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// cursorArgs maps a nil cursor to a position before the first row in the
+// requested direction, -infinity ascending and infinity descending, so the
+// first page is returned.
+func cursorArgs(c *Cursor, ascending bool) (pgtype.Timestamptz, pgtype.UUID) {
+	if c != nil {
+		return pgtype.Timestamptz{Time: c.CreatedAt, Valid: true}, pgtype.UUID{Bytes: c.ID, Valid: true}
+	}
+	if ascending {
+		return pgtype.Timestamptz{InfinityModifier: pgtype.NegativeInfinity, Valid: true}, pgtype.UUID{Bytes: uuid.Nil, Valid: true}
+	}
+	return pgtype.Timestamptz{InfinityModifier: pgtype.Infinity, Valid: true}, pgtype.UUID{Bytes: uuid.Max, Valid: true}
+}
+
+// optionalTimestamptz maps a nil time to SQL NULL.
+func optionalTimestamptz(t *time.Time) pgtype.Timestamptz {
+	if t == nil {
+		return pgtype.Timestamptz{}
+	}
+	return pgtype.Timestamptz{Time: *t, Valid: true}
+}
+
+// optionalUUID maps a nil UUID to SQL NULL.
+func optionalUUID(id *uuid.UUID) pgtype.UUID {
+	if id == nil {
+		return pgtype.UUID{}
+	}
+	return pgtype.UUID{Bytes: *id, Valid: true}
+}
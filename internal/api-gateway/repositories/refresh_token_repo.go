@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	db "github.com/akshaysangma/go-serve/internal/database/postgres/sqlc"
+	"github.com/google/uuid"
+)
+
+type RefreshToken = db.RefreshToken
+
+type CreateRefreshTokenParams struct {
+	UserID    uuid.UUID
+	FamilyID  uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+// RefreshTokenRepository defines the interface for refresh token data operations.
+type RefreshTokenRepository interface {
+	CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error)
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (RefreshToken, error)
+	// MarkRefreshTokenRotated reports false if the token was already rotated or revoked.
+	MarkRefreshTokenRotated(ctx context.Context, id uuid.UUID) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error
+}
+
+type postgresRefreshTokenRepository struct {
+	queries *db.Queries
+}
+
+func NewRefreshTokenRepository(queries *db.Queries) RefreshTokenRepository {
+	return &postgresRefreshTokenRepository{
+		queries: queries,
+	}
+}
+
+func (r *postgresRefreshTokenRepository) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error) {
+	token, err := r.queries.CreateRefreshToken(ctx, db.CreateRefreshTokenParams{
+		UserID:    arg.UserID,
+		FamilyID:  arg.FamilyID,
+		TokenHash: arg.TokenHash,
+		ExpiresAt: arg.ExpiresAt,
+	})
+	if err != nil {
+		return RefreshToken{}, fmt.Errorf("repo: failed to create refresh token: %w", err)
+	}
+	return token, nil
+}
+
+func (r *postgresRefreshTokenRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	token, err := r.queries.GetRefreshTokenByHash(ctx, tokenHash)
+	if err != nil {
+		return RefreshToken{}, fmt.Errorf("repo: failed to get refresh token by hash: %w", err)
+	}
+	return token, nil
+}
+
+func (r *postgresRefreshTokenRepository) MarkRefreshTokenRotated(ctx context.Context, id uuid.UUID) (bool, error) {
+	rows, err := r.queries.MarkRefreshTokenRotated(ctx, id)
+	if err != nil {
+		return false, fmt.Errorf("repo: failed to mark refresh token rotated: %w", err)
+	}
+	return rows == 1, nil
+}
+
+func (r *postgresRefreshTokenRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	err := r.queries.RevokeRefreshTokenFamily(ctx, familyID)
+	if err != nil {
+		return fmt.Errorf("repo: failed to revoke refresh token family: %w", err)
+	}
+	return nil
+}
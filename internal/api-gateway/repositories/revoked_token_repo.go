@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	db "github.com/akshaysangma/go-serve/internal/database/postgres/sqlc"
+)
+
+// RevokedTokenRepository defines the interface for the access token denylist.
+type RevokedTokenRepository interface {
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	DeleteExpiredRevokedTokens(ctx context.Context) (int64, error)
+}
+
+type postgresRevokedTokenRepository struct {
+	queries *db.Queries
+}
+
+func NewRevokedTokenRepository(queries *db.Queries) RevokedTokenRepository {
+	return &postgresRevokedTokenRepository{
+		queries: queries,
+	}
+}
+
+func (r *postgresRevokedTokenRepository) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	err := r.queries.RevokeToken(ctx, db.RevokeTokenParams{
+		Jti:       jti,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return fmt.Errorf("repo: failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+func (r *postgresRevokedTokenRepository) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	revoked, err := r.queries.IsTokenRevoked(ctx, jti)
+	if err != nil {
+		return false, fmt.Errorf("repo: failed to check token revocation: %w", err)
+	}
+	return revoked, nil
+}
+
+func (r *postgresRevokedTokenRepository) DeleteExpiredRevokedTokens(ctx context.Context) (int64, error) {
+	deleted, err := r.queries.DeleteExpiredRevokedTokens(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("repo: failed to delete expired revoked tokens: %w", err)
+	}
+	return deleted, nil
+}
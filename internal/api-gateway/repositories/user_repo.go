@@ -3,22 +3,38 @@ package repositories
 import (
 	"context"
 	"fmt"
+	"time"
 
 	db "github.com/akshaysangma/go-serve/internal/database/postgres/sqlc"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type User = db.User
 
 type CreateUserParams struct {
-	Username string
-	Email    string
+	Username     string
+	Email        string
+	PasswordHash string
 }
 
+// UpdateUserParams describes a partial update; nil fields are left unchanged.
 type UpdateUserParams struct {
 	ID       uuid.UUID
-	Username string
-	Email    string
+	Username *string
+	Email    *string
+}
+
+// ListUsersParams selects one page of users. Nil filters match every user and
+// a nil After starts at the first page.
+type ListUsersParams struct {
+	UsernamePrefix *string
+	EmailDomain    *string
+	CreatedAfter   *time.Time
+	CreatedBefore  *time.Time
+	After          *Cursor
+	Ascending      bool
+	Limit          int32
 }
 
 // UserRepository defines the interface for user data operations.
@@ -26,9 +42,15 @@ type UserRepository interface {
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	GetUserByID(ctx context.Context, id uuid.UUID) (User, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error) // Add this query if not already
-	ListUsers(ctx context.Context) ([]User, error)
+	ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
 	DeleteUser(ctx context.Context, id uuid.UUID) error
+	ListUserRoles(ctx context.Context, userID uuid.UUID) ([]string, error)
+	AssignUserRole(ctx context.Context, userID uuid.UUID, role string) error
+	RemoveUserRole(ctx context.Context, userID uuid.UUID, role string) error
+	UpdateUserPlan(ctx context.Context, userID uuid.UUID, plan string) (User, error)
+	// UpdateUserPassword reports false if no user id exists.
+	UpdateUserPassword(ctx context.Context, userID uuid.UUID, passwordHash string) (bool, error)
 }
 
 type postgresUserRepository struct {
@@ -44,8 +66,9 @@ func NewUserRepository(queries *db.Queries) UserRepository {
 // Implement methods from the UserRepository interface
 func (r *postgresUserRepository) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
 	user, err := r.queries.CreateUser(ctx, db.CreateUserParams{
-		Username: arg.Username,
-		Email:    arg.Email,
+		Username:     arg.Username,
+		Email:        arg.Email,
+		PasswordHash: arg.PasswordHash,
 	})
 	if err != nil {
 		return User{}, fmt.Errorf("repo: failed to create user: %w", err)
@@ -69,8 +92,24 @@ func (r *postgresUserRepository) GetUserByEmail(ctx context.Context, email strin
 	return user, nil
 }
 
-func (r *postgresUserRepository) ListUsers(ctx context.Context) ([]User, error) {
-	users, err := r.queries.ListUsers(ctx)
+func (r *postgresUserRepository) ListUsers(ctx context.Context, arg ListUsersParams) ([]User, error) {
+	cursorCreatedAt, cursorID := cursorArgs(arg.After, arg.Ascending)
+	params := db.ListUsersAscendingParams{
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		UsernamePrefix:  optionalText(arg.UsernamePrefix),
+		EmailDomain:     optionalText(arg.EmailDomain),
+		CreatedAfter:    optionalTimestamptz(arg.CreatedAfter),
+		CreatedBefore:   optionalTimestamptz(arg.CreatedBefore),
+		PageLimit:       arg.Limit,
+	}
+	var users []User
+	var err error
+	if arg.Ascending {
+		users, err = r.queries.ListUsersAscending(ctx, params)
+	} else {
+		users, err = r.queries.ListUsersDescending(ctx, db.ListUsersDescendingParams(params))
+	}
 	if err != nil {
 		return nil, fmt.Errorf("repo: failed to list users: %w", err)
 	}
@@ -80,8 +119,8 @@ func (r *postgresUserRepository) ListUsers(ctx context.Context) ([]User, error)
 func (r *postgresUserRepository) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
 	user, err := r.queries.UpdateUser(ctx, db.UpdateUserParams{
 		ID:       arg.ID,
-		Username: arg.Username,
-		Email:    arg.Email,
+		Username: optionalText(arg.Username),
+		Email:    optionalText(arg.Email),
 	})
 	if err != nil {
 		return User{}, fmt.Errorf("repo: failed to update user: %w", err)
@@ -96,3 +135,63 @@ func (r *postgresUserRepository) DeleteUser(ctx context.Context, id uuid.UUID) e
 	}
 	return nil
 }
+
+func (r *postgresUserRepository) ListUserRoles(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	roles, err := r.queries.ListUserRoles(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("repo: failed to list user roles: %w", err)
+	}
+	return roles, nil
+}
+
+func (r *postgresUserRepository) AssignUserRole(ctx context.Context, userID uuid.UUID, role string) error {
+	err := r.queries.AssignUserRole(ctx, db.AssignUserRoleParams{
+		UserID: userID,
+		Role:   role,
+	})
+	if err != nil {
+		return fmt.Errorf("repo: failed to assign user role: %w", err)
+	}
+	return nil
+}
+
+func (r *postgresUserRepository) RemoveUserRole(ctx context.Context, userID uuid.UUID, role string) error {
+	err := r.queries.RemoveUserRole(ctx, db.RemoveUserRoleParams{
+		UserID: userID,
+		Role:   role,
+	})
+	if err != nil {
+		return fmt.Errorf("repo: failed to remove user role: %w", err)
+	}
+	return nil
+}
+
+func (r *postgresUserRepository) UpdateUserPlan(ctx context.Context, userID uuid.UUID, plan string) (User, error) {
+	user, err := r.queries.UpdateUserPlan(ctx, db.UpdateUserPlanParams{
+		ID:   userID,
+		Plan: plan,
+	})
+	if err != nil {
+		return User{}, fmt.Errorf("repo: failed to update user plan: %w", err)
+	}
+	return user, nil
+}
+
+func (r *postgresUserRepository) UpdateUserPassword(ctx context.Context, userID uuid.UUID, passwordHash string) (bool, error) {
+	rows, err := r.queries.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+		ID:           userID,
+		PasswordHash: passwordHash,
+	})
+	if err != nil {
+		return false, fmt.Errorf("repo: failed to update user password: %w", err)
+	}
+	return rows == 1, nil
+}
+
+// optionalText maps a nil string to SQL NULL.
+func optionalText(s *string) pgtype.Text {
+	if s == nil {
+		return pgtype.Text{}
+	}
+	return pgtype.Text{String: *s, Valid: true}
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/akshaysangma/go-serve/internal/api-gateway/auth"
+	"github.com/akshaysangma/go-serve/internal/api-gateway/repositories"
+	db "github.com/akshaysangma/go-serve/internal/database/postgres/sqlc"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	// apiKeyTag starts every key so leaked keys are easy to spot in logs and scanners.
+	apiKeyTag         = "gsk_"
+	apiKeyPrefixBytes = 4
+	apiKeySecretBytes = 32
+)
+
+var (
+	// ErrInvalidAPIKey is returned for unknown, expired or revoked API keys.
+	ErrInvalidAPIKey = fmt.Errorf("%w: invalid API key", auth.ErrUnauthenticated)
+	// ErrNotAPIKeyOwner is returned when a user tries to revoke someone else's key.
+	ErrNotAPIKeyOwner = errors.New("user does not own the API key")
+	// ErrInvalidAPIKeyRequest is returned for missing names or expiry times in the past.
+	ErrInvalidAPIKeyRequest = errors.New("API key needs a name and a future expiry")
+	// ErrUnknownAPIKeyScope is returned when a key is requested with a scope
+	// that is not in auth.Scopes.
+	ErrUnknownAPIKeyScope = errors.New("unknown API key scope")
+)
+
+// APIKeyService manages long-lived API keys for non-interactive clients.
+type APIKeyService struct {
+	apiKeyRepo repositories.APIKeyRepository
+	userRepo   repositories.UserRepository
+	logger     *zap.Logger
+}
+
+// NewAPIKeyService creates a new APIKeyService.
+func NewAPIKeyService(apiKeyRepo repositories.APIKeyRepository, userRepo repositories.UserRepository, logger *zap.Logger) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+		logger:     logger,
+	}
+}
+
+// CreateAPIKey creates a key acting as userID and returns the plaintext key.
+// The plaintext is not stored and cannot be retrieved again.
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, userID uuid.UUID, name string, scopes []string, expiresAt *time.Time) (string, db.APIKey, error) {
+	if strings.TrimSpace(name) == "" || (expiresAt != nil && !expiresAt.After(time.Now())) {
+		return "", db.APIKey{}, ErrInvalidAPIKeyRequest
+	}
+	for _, scope := range scopes {
+		if !auth.IsScope(scope) {
+			return "", db.APIKey{}, fmt.Errorf("%w %q", ErrUnknownAPIKeyScope, scope)
+		}
+	}
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	prefix, plaintext, err := generateAPIKey()
+	if err != nil {
+		s.logger.Error("Service: Failed to generate API key", zap.Error(err))
+		return "", db.APIKey{}, err
+	}
+
+	key, err := s.apiKeyRepo.CreateAPIKey(ctx, repositories.CreateAPIKeyParams{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   hashAPIKey(plaintext),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		s.logger.Error("Service: Failed to create API key via repository", zap.Error(err), zap.String("user_id", userID.String()))
+		return "", db.APIKey{}, fmt.Errorf("could not create API key: %w", err)
+	}
+
+	s.logger.Info("Service: API key created", zap.String("user_id", userID.String()), zap.String("api_key_id", key.ID.String()), zap.String("prefix", prefix))
+	return plaintext, key, nil
+}
+
+// ListAPIKeys lists the keys owned by userID, including revoked ones.
+func (s *APIKeyService) ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]db.APIKey, error) {
+	keys, err := s.apiKeyRepo.ListAPIKeysByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Error("Service: Failed to list API keys via repository", zap.Error(err), zap.String("user_id", userID.String()))
+		return nil, fmt.Errorf("could not list API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey revokes a key. Only its owner or an admin may revoke it.
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, id uuid.UUID, principal *auth.Principal) error {
+	key, err := s.apiKeyRepo.GetAPIKeyByID(ctx, id)
+	if err != nil {
+		s.logger.Error("Service: Failed to get API key via repository", zap.Error(err), zap.String("api_key_id", id.String()))
+		return fmt.Errorf("could not revoke API key: %w", err)
+	}
+	if key.UserID != principal.UserID && !principal.HasRole(auth.RoleAdmin) {
+		return ErrNotAPIKeyOwner
+	}
+
+	if err := s.apiKeyRepo.RevokeAPIKey(ctx, id); err != nil {
+		s.logger.Error("Service: Failed to revoke API key via repository", zap.Error(err), zap.String("api_key_id", id.String()))
+		return fmt.Errorf("could not revoke API key: %w", err)
+	}
+
+	s.logger.Info("Service: API key revoked", zap.String("api_key_id", id.String()), zap.String("revoked_by", principal.UserID.String()))
+	return nil
+}
+
+// AuthenticateAPIKey resolves a plaintext key to a principal acting as the
+// key's owner with the key's scopes, and records that the key was used.
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, plaintext string) (*auth.Principal, error) {
+	if !strings.HasPrefix(plaintext, apiKeyTag) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.apiKeyRepo.GetAPIKeyByHash(ctx, hashAPIKey(plaintext))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, fmt.Errorf("could not authenticate API key: %w", err)
+	}
+	if key.RevokedAt.Valid || (key.ExpiresAt.Valid && !time.Now().Before(key.ExpiresAt.Time)) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, key.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("could not load API key owner: %w", err)
+	}
+	roles, err := s.userRepo.ListUserRoles(ctx, key.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("could not load API key owner roles: %w", err)
+	}
+
+	if err := s.apiKeyRepo.TouchAPIKeyLastUsed(ctx, key.ID); err != nil {
+		// Usage tracking is best effort and must not block the request.
+		s.logger.Warn("Service: Failed to record API key usage", zap.Error(err), zap.String("api_key_id", key.ID.String()))
+	}
+
+	principal := &auth.Principal{
+		Method:   auth.MethodAPIKey,
+		UserID:   user.ID,
+		Email:    user.Email,
+		Username: user.Username,
+		Roles:    roles,
+		Scopes:   key.Scopes,
+		Plan:     user.Plan,
+		TokenID:  key.ID.String(),
+	}
+	if key.ExpiresAt.Valid {
+		principal.ExpiresAt = key.ExpiresAt.Time
+	}
+	return principal, nil
+}
+
+// generateAPIKey returns the visible prefix and the full plaintext key,
+// formatted as gsk_<prefix>_<secret>.
+func generateAPIKey() (string, string, error) {
+	raw := make([]byte, apiKeyPrefixBytes+apiKeySecretBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", fmt.Errorf("could not generate API key: %w", err)
+	}
+
+	prefix := apiKeyTag + hex.EncodeToString(raw[:apiKeyPrefixBytes])
+	return prefix, prefix + "_" + base64.RawURLEncoding.EncodeToString(raw[apiKeyPrefixBytes:]), nil
+}
+
+// hashAPIKey returns the value stored in place of the key. Keys carry 256
+// bits of randomness, so a fast unsalted hash is sufficient.
+func hashAPIKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
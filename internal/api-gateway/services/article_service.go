@@ -2,14 +2,21 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/akshaysangma/go-serve/internal/api-gateway/repositories"
 	db "github.com/akshaysangma/go-serve/internal/database/postgres/sqlc"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
+// ErrNotArticleAuthor is returned when a user tries to modify an article they did not write.
+var ErrNotArticleAuthor = errors.New("user is not the author of the article")
+
 // ArticleService handles business logic for articles.
 type ArticleService struct {
 	articleRepo repositories.ArticleRepository
@@ -25,7 +32,10 @@ func NewArticleService(articleRepo repositories.ArticleRepository, logger *zap.L
 }
 
 // CreateArticle creates a new article.
-func (s *ArticleService) CreateArticle(ctx context.Context, title, content string, authorID uuid.UUID) (db.Article, error) {
+func (s *ArticleService) CreateArticle(ctx context.Context, title, content string, authorID uuid.UUID) (_ db.Article, err error) {
+	ctx, span := startSpan(ctx, "ArticleService.CreateArticle")
+	defer func() { endSpan(span, err) }()
+
 	article, err := s.articleRepo.CreateArticle(ctx, repositories.CreateArticleParams{
 		Title:    title,
 		Content:  content,
@@ -39,7 +49,10 @@ func (s *ArticleService) CreateArticle(ctx context.Context, title, content strin
 }
 
 // GetArticleByID retrieves an article by ID.
-func (s *ArticleService) GetArticleByID(ctx context.Context, id uuid.UUID) (db.Article, error) {
+func (s *ArticleService) GetArticleByID(ctx context.Context, id uuid.UUID) (_ db.Article, err error) {
+	ctx, span := startSpan(ctx, "ArticleService.GetArticleByID", attribute.String("article.id", id.String()))
+	defer func() { endSpan(span, err) }()
+
 	article, err := s.articleRepo.GetArticleByID(ctx, id)
 	if err != nil {
 		s.logger.Error("Service: Failed to get article by ID via repository", zap.Error(err), zap.String("article_id", id.String()))
@@ -48,23 +61,57 @@ func (s *ArticleService) GetArticleByID(ctx context.Context, id uuid.UUID) (db.A
 	return article, nil
 }
 
-// ListArticles lists all articles.
-func (s *ArticleService) ListArticles(ctx context.Context) ([]db.Article, error) {
-	articles, err := s.articleRepo.ListArticles(ctx)
+// ArticleFilter narrows a list of articles. Nil fields match every article.
+type ArticleFilter struct {
+	AuthorID      *uuid.UUID
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}
+
+// ListArticles returns one page of the articles matching filter, newest first
+// unless the page asks for ascending order.
+func (s *ArticleService) ListArticles(ctx context.Context, filter ArticleFilter, page PageRequest) (_ Page[db.Article], err error) {
+	ctx, span := startSpan(ctx, "ArticleService.ListArticles")
+	defer func() { endSpan(span, err) }()
+
+	after, err := decodeCursor(page.Cursor, page.Ascending)
+	if err != nil {
+		return Page[db.Article]{}, err
+	}
+
+	limit := page.limit()
+	articles, err := s.articleRepo.ListArticles(ctx, repositories.ListArticlesParams{
+		AuthorID:      filter.AuthorID,
+		CreatedAfter:  filter.CreatedAfter,
+		CreatedBefore: filter.CreatedBefore,
+		After:         after,
+		Ascending:     page.Ascending,
+		Limit:         int32(limit + 1),
+	})
 	if err != nil {
 		s.logger.Error("Service: Failed to list articles via repository", zap.Error(err))
-		return nil, fmt.Errorf("could not list articles: %w", err)
+		return Page[db.Article]{}, fmt.Errorf("could not list articles: %w", err)
 	}
-	return articles, nil
+	return newPage(articles, limit, page.Ascending, func(a db.Article) (time.Time, uuid.UUID) {
+		return a.CreatedAt.Time, a.ID
+	}), nil
 }
 
-// UpdateArticle updates an existing article.
-func (s *ArticleService) UpdateArticle(ctx context.Context, id uuid.UUID, title, content string) (db.Article, error) {
+// UpdateArticle updates an existing article on behalf of its author.
+// Nil title or content leaves the stored value untouched.
+func (s *ArticleService) UpdateArticle(ctx context.Context, id, authorID uuid.UUID, title, content *string) (_ db.Article, err error) {
+	ctx, span := startSpan(ctx, "ArticleService.UpdateArticle", attribute.String("article.id", id.String()))
+	defer func() { endSpan(span, err) }()
+
 	article, err := s.articleRepo.UpdateArticle(ctx, repositories.UpdateArticleParams{
-		ID:      id,
-		Title:   title,
-		Content: content,
+		ID:       id,
+		AuthorID: authorID,
+		Title:    title,
+		Content:  content,
 	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.Article{}, s.rejectArticleWrite(ctx, id, authorID)
+	}
 	if err != nil {
 		s.logger.Error("Service: Failed to update article via repository", zap.Error(err), zap.String("article_id", id.String()))
 		return db.Article{}, fmt.Errorf("could not update article: %w", err)
@@ -72,12 +119,32 @@ func (s *ArticleService) UpdateArticle(ctx context.Context, id uuid.UUID, title,
 	return article, nil
 }
 
-// DeleteArticle deletes an article by ID.
-func (s *ArticleService) DeleteArticle(ctx context.Context, id uuid.UUID) error {
-	err := s.articleRepo.DeleteArticle(ctx, id)
+// DeleteArticle deletes an article by ID on behalf of its author.
+func (s *ArticleService) DeleteArticle(ctx context.Context, id, authorID uuid.UUID) (err error) {
+	ctx, span := startSpan(ctx, "ArticleService.DeleteArticle", attribute.String("article.id", id.String()))
+	defer func() { endSpan(span, err) }()
+
+	deleted, err := s.articleRepo.DeleteArticle(ctx, id, authorID)
 	if err != nil {
 		s.logger.Error("Service: Failed to delete article via repository", zap.Error(err), zap.String("article_id", id.String()))
 		return fmt.Errorf("could not delete article: %w", err)
 	}
+	if !deleted {
+		return s.rejectArticleWrite(ctx, id, authorID)
+	}
 	return nil
 }
+
+// rejectArticleWrite explains a write that matched no article written by
+// authorID. The write itself checks the author, so this lookup only picks
+// the error: not found if the article does not exist, ErrNotArticleAuthor
+// otherwise.
+func (s *ArticleService) rejectArticleWrite(ctx context.Context, id, authorID uuid.UUID) error {
+	if _, err := s.GetArticleByID(ctx, id); err != nil {
+		return err
+	}
+	s.logger.Warn("Service: Rejected article write by non-author",
+		zap.String("article_id", id.String()),
+		zap.String("user_id", authorID.String()))
+	return ErrNotArticleAuthor
+}
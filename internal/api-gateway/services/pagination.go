@@ -0,0 +1,87 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/akshaysangma/go-serve/internal/api-gateway/repositories"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultPageLimit is used when a list request does not ask for a limit.
+	DefaultPageLimit = 20
+	// MaxPageLimit is the largest page a list request may ask for.
+	MaxPageLimit = 100
+)
+
+// ErrInvalidCursor is returned when a page cursor was not issued by this API,
+// or was issued for the other sort order.
+var ErrInvalidCursor = errors.New("invalid page cursor")
+
+// PageRequest asks for the page after Cursor, which is empty for the first
+// page. Limit is clamped to [1, MaxPageLimit], with 0 meaning DefaultPageLimit.
+type PageRequest struct {
+	Cursor    string
+	Limit     int
+	Ascending bool
+}
+
+// Page is one page of a list. NextCursor is empty on the last page.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// pageCursor is the JSON form of an opaque cursor token. Ascending records
+// the sort order it was issued for, since a position only makes sense when
+// paging on in the same direction.
+type pageCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+	Ascending bool      `json:"asc"`
+}
+
+func encodeCursor(createdAt time.Time, id uuid.UUID, ascending bool) string {
+	b, _ := json.Marshal(pageCursor{CreatedAt: createdAt, ID: id, Ascending: ascending})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(token string, ascending bool) (*repositories.Cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c pageCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.CreatedAt.IsZero() || c.ID == uuid.Nil || c.Ascending != ascending {
+		return nil, ErrInvalidCursor
+	}
+	return &repositories.Cursor{CreatedAt: c.CreatedAt, ID: c.ID}, nil
+}
+
+func (p PageRequest) limit() int {
+	switch {
+	case p.Limit <= 0:
+		return DefaultPageLimit
+	case p.Limit > MaxPageLimit:
+		return MaxPageLimit
+	default:
+		return p.Limit
+	}
+}
+
+// newPage trims the extra row fetched to detect a following page and, if
+// there was one, returns a cursor pointing at the last row kept.
+func newPage[T any](items []T, limit int, ascending bool, key func(T) (time.Time, uuid.UUID)) Page[T] {
+	if len(items) <= limit {
+		return Page[T]{Items: items}
+	}
+	items = items[:limit]
+	createdAt, id := key(items[limit-1])
+	return Page[T]{Items: items, NextCursor: encodeCursor(createdAt, id, ascending)}
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	minPasswordLength = 8
+	// bcrypt silently ignores (or rejects, depending on version) input past 72 bytes.
+	maxPasswordLength = 72
+)
+
+var (
+	// ErrInvalidCredentials is returned for both unknown emails and wrong passwords
+	// so callers cannot tell which one failed.
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	// ErrInvalidPassword is returned when a new password does not meet the length policy.
+	ErrInvalidPassword = fmt.Errorf("password must be between %d and %d bytes", minPasswordLength, maxPasswordLength)
+)
+
+// dummyPasswordHash is compared against when no user matches the login email,
+// so unknown emails cost the same bcrypt work as wrong passwords.
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("go-serve-dummy-password"), bcrypt.DefaultCost)
+
+func hashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return "", ErrInvalidPassword
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("could not hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// checkPassword reports whether password matches hash. An empty hash (no
+// password set, or unknown user) is checked against dummyPasswordHash and
+// always fails.
+func checkPassword(hash, password string) bool {
+	if hash == "" {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/akshaysangma/go-serve/internal/api-gateway/repositories"
+	db "github.com/akshaysangma/go-serve/internal/database/postgres/sqlc"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+const refreshTokenBytes = 32
+
+var (
+	// ErrInvalidRefreshToken is returned for unknown, expired or revoked refresh tokens.
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused is returned when an already rotated token is presented again.
+	// The whole token family is revoked before it is returned.
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+// RefreshTokenService issues and rotates opaque refresh tokens.
+type RefreshTokenService struct {
+	refreshTokenRepo repositories.RefreshTokenRepository
+	dBConn           *pgxpool.Pool
+	ttl              time.Duration
+	logger           *zap.Logger
+}
+
+// NewRefreshTokenService creates a new RefreshTokenService issuing tokens valid for ttl.
+func NewRefreshTokenService(
+	refreshTokenRepo repositories.RefreshTokenRepository,
+	dBConn *pgxpool.Pool,
+	ttl time.Duration,
+	logger *zap.Logger,
+) *RefreshTokenService {
+	return &RefreshTokenService{
+		refreshTokenRepo: refreshTokenRepo,
+		dBConn:           dBConn,
+		ttl:              ttl,
+		logger:           logger,
+	}
+}
+
+// Issue starts a new token family for userID and returns its first token.
+func (s *RefreshTokenService) Issue(ctx context.Context, userID uuid.UUID) (string, error) {
+	token, err := s.create(ctx, s.refreshTokenRepo, userID, uuid.New())
+	if err != nil {
+		s.logger.Error("Service: Failed to issue refresh token", zap.Error(err), zap.String("user_id", userID.String()))
+		return "", fmt.Errorf("could not issue refresh token: %w", err)
+	}
+	return token, nil
+}
+
+// Rotate exchanges a live refresh token for a new one in the same family and
+// returns the new token with the owning user ID. Presenting a token that was
+// already rotated revokes the entire family.
+func (s *RefreshTokenService) Rotate(ctx context.Context, token string) (string, uuid.UUID, error) {
+	current, err := s.refreshTokenRepo.GetRefreshTokenByHash(ctx, hashRefreshToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", uuid.Nil, ErrInvalidRefreshToken
+		}
+		s.logger.Error("Service: Failed to get refresh token via repository", zap.Error(err))
+		return "", uuid.Nil, fmt.Errorf("could not rotate refresh token: %w", err)
+	}
+
+	// Reuse is checked first: a rotated token replayed after it expired or
+	// after its family was revoked still revokes the family.
+	if current.RotatedAt.Valid {
+		return "", uuid.Nil, s.revokeReusedFamily(ctx, current)
+	}
+	if current.RevokedAt.Valid || time.Now().After(current.ExpiresAt) {
+		return "", uuid.Nil, ErrInvalidRefreshToken
+	}
+
+	tx, err := s.dBConn.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		s.logger.Error("Service: Failed to create transactions", zap.Error(err))
+		return "", uuid.Nil, fmt.Errorf("could not begin transaction %w", err)
+	}
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil && err != pgx.ErrTxClosed {
+			s.logger.Error("Service: Failed to rollback transactions", zap.Error(err))
+		}
+	}()
+
+	txRefreshTokenRepo := repositories.NewRefreshTokenRepository(db.New(tx))
+
+	rotated, err := txRefreshTokenRepo.MarkRefreshTokenRotated(ctx, current.ID)
+	if err != nil {
+		s.logger.Error("Service: Failed to mark refresh token rotated within transaction", zap.Error(err))
+		return "", uuid.Nil, fmt.Errorf("could not rotate refresh token: %w", err)
+	}
+	if !rotated {
+		// Lost a race against another rotation of the same token.
+		tx.Rollback(ctx)
+		return "", uuid.Nil, s.revokeReusedFamily(ctx, current)
+	}
+
+	next, err := s.create(ctx, txRefreshTokenRepo, current.UserID, current.FamilyID)
+	if err != nil {
+		s.logger.Error("Service: Failed to create rotated refresh token within transaction", zap.Error(err))
+		return "", uuid.Nil, fmt.Errorf("could not rotate refresh token: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		s.logger.Error("Service: Failed to commit transaction for refresh token rotation", zap.Error(err))
+		return "", uuid.Nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+
+	return next, current.UserID, nil
+}
+
+// Revoke revokes the family of the given refresh token, ending every session
+// rotated from the same login. Unknown tokens are ignored.
+func (s *RefreshTokenService) Revoke(ctx context.Context, token string) error {
+	current, err := s.refreshTokenRepo.GetRefreshTokenByHash(ctx, hashRefreshToken(token))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		s.logger.Error("Service: Failed to get refresh token via repository", zap.Error(err))
+		return fmt.Errorf("could not revoke refresh token: %w", err)
+	}
+
+	if err := s.refreshTokenRepo.RevokeRefreshTokenFamily(ctx, current.FamilyID); err != nil {
+		s.logger.Error("Service: Failed to revoke refresh token family", zap.Error(err), zap.String("family_id", current.FamilyID.String()))
+		return fmt.Errorf("could not revoke refresh token: %w", err)
+	}
+	return nil
+}
+
+func (s *RefreshTokenService) create(ctx context.Context, repo repositories.RefreshTokenRepository, userID, familyID uuid.UUID) (string, error) {
+	raw := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("could not generate refresh token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	_, err := repo.CreateRefreshToken(ctx, repositories.CreateRefreshTokenParams{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashRefreshToken(token),
+		ExpiresAt: time.Now().Add(s.ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *RefreshTokenService) revokeReusedFamily(ctx context.Context, reused db.RefreshToken) error {
+	s.logger.Warn("Service: Refresh token reuse detected, revoking family",
+		zap.String("user_id", reused.UserID.String()),
+		zap.String("family_id", reused.FamilyID.String()))
+
+	if err := s.refreshTokenRepo.RevokeRefreshTokenFamily(ctx, reused.FamilyID); err != nil {
+		s.logger.Error("Service: Failed to revoke refresh token family", zap.Error(err), zap.String("family_id", reused.FamilyID.String()))
+		return fmt.Errorf("could not revoke refresh token family: %w", err)
+	}
+	return ErrRefreshTokenReused
+}
+
+// hashRefreshToken returns the value stored in place of token. Refresh tokens
+// carry 256 bits of randomness, so a fast unsalted hash is sufficient.
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
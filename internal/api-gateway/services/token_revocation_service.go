@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/akshaysangma/go-serve/internal/api-gateway/repositories"
+	"go.uber.org/zap"
+)
+
+// notRevokedCacheTTL is how long a token found not revoked is trusted
+// without asking the database again. It bounds how long another replica
+// keeps accepting a token after it is revoked.
+const notRevokedCacheTTL = 10 * time.Second
+
+// TokenRevocationService maintains the access token denylist. Lookups are
+// cached in memory: revoked token IDs until the token would have expired,
+// and tokens that are not revoked for notRevokedCacheTTL, so most requests
+// do not hit the database.
+type TokenRevocationService struct {
+	revokedTokenRepo repositories.RevokedTokenRepository
+	logger           *zap.Logger
+
+	mu        sync.RWMutex
+	entries   map[string]revocationEntry // keyed by jti
+	lastSweep time.Time
+}
+
+// revocationEntry is a cached revocation lookup, valid until until.
+type revocationEntry struct {
+	revoked bool
+	until   time.Time
+}
+
+// NewTokenRevocationService creates a new TokenRevocationService.
+func NewTokenRevocationService(revokedTokenRepo repositories.RevokedTokenRepository, logger *zap.Logger) *TokenRevocationService {
+	return &TokenRevocationService{
+		revokedTokenRepo: revokedTokenRepo,
+		logger:           logger,
+		entries:          make(map[string]revocationEntry),
+	}
+}
+
+// Revoke denylists the token with the given jti until it expires.
+func (s *TokenRevocationService) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	if !time.Now().Before(expiresAt) {
+		return nil
+	}
+
+	if err := s.revokedTokenRepo.RevokeToken(ctx, jti, expiresAt); err != nil {
+		s.logger.Error("Service: Failed to revoke token via repository", zap.Error(err))
+		return fmt.Errorf("could not revoke token: %w", err)
+	}
+
+	s.cache(jti, revocationEntry{revoked: true, until: expiresAt})
+	return nil
+}
+
+// IsRevoked reports whether the token with the given jti has been revoked.
+// expiresAt bounds how long the answer is cached.
+func (s *TokenRevocationService) IsRevoked(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	now := time.Now()
+	s.mu.RLock()
+	entry, ok := s.entries[jti]
+	s.mu.RUnlock()
+	if ok && now.Before(entry.until) {
+		return entry.revoked, nil
+	}
+
+	revoked, err := s.revokedTokenRepo.IsTokenRevoked(ctx, jti)
+	if err != nil {
+		s.logger.Error("Service: Failed to check token revocation via repository", zap.Error(err))
+		return false, fmt.Errorf("could not check token revocation: %w", err)
+	}
+
+	entry = revocationEntry{revoked: revoked, until: expiresAt}
+	if until := now.Add(notRevokedCacheTTL); !revoked && until.Before(expiresAt) {
+		entry.until = until
+	}
+	s.cache(jti, entry)
+	return revoked, nil
+}
+
+// RunCleanup purges expired entries from the cache and the database every
+// interval until ctx is cancelled.
+func (s *TokenRevocationService) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.purgeCache()
+			deleted, err := s.revokedTokenRepo.DeleteExpiredRevokedTokens(ctx)
+			if err != nil {
+				s.logger.Error("Service: Failed to purge expired revoked tokens", zap.Error(err))
+				continue
+			}
+			s.logger.Debug("Service: Purged expired revoked tokens", zap.Int64("count", deleted))
+		}
+	}
+}
+
+// cache stores entry unless it would replace a revocation, which is final.
+func (s *TokenRevocationService) cache(jti string, entry revocationEntry) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Every token seen gets an entry, so expired ones are dropped once a
+	// minute rather than waiting for RunCleanup.
+	if now.Sub(s.lastSweep) > time.Minute {
+		s.sweep(now)
+	}
+	if current, ok := s.entries[jti]; ok && current.revoked && !entry.revoked {
+		return
+	}
+	s.entries[jti] = entry
+}
+
+func (s *TokenRevocationService) purgeCache() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(time.Now())
+}
+
+// sweep drops expired entries. s.mu must be held.
+func (s *TokenRevocationService) sweep(now time.Time) {
+	s.lastSweep = now
+	for jti, entry := range s.entries {
+		if !now.Before(entry.until) {
+			delete(s.entries, jti)
+		}
+	}
+}
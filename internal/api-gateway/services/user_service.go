@@ -2,16 +2,33 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/akshaysangma/go-serve/internal/api-gateway/auth"
 	"github.com/akshaysangma/go-serve/internal/api-gateway/repositories"
 	db "github.com/akshaysangma/go-serve/internal/database/postgres/sqlc"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/zap"
 )
 
+// ErrUnknownRole is returned when assigning a role that is not in the roles table.
+var ErrUnknownRole = errors.New("unknown role")
+
+// ErrUnknownPlan is returned when setting a plan rejected by chk_user_plan.
+var ErrUnknownPlan = errors.New("unknown plan")
+
+// pgForeignKeyViolation is the SQLSTATE raised when a referenced row does not exist.
+const pgForeignKeyViolation = "23503"
+
+// pgCheckViolation is the SQLSTATE raised when a CHECK constraint fails.
+const pgCheckViolation = "23514"
+
 type UserService struct {
 	userRepo    repositories.UserRepository
 	articleRepo repositories.ArticleRepository
@@ -33,18 +50,36 @@ func NewUserService(
 	}
 }
 
-func (s *UserService) CreateUser(ctx context.Context, username, email string) (db.User, error) {
+func (s *UserService) CreateUser(ctx context.Context, username, email, password string) (_ db.User, err error) {
+	ctx, span := startSpan(ctx, "UserService.CreateUser")
+	defer func() { endSpan(span, err) }()
+
+	passwordHash, err := hashPassword(password)
+	if err != nil {
+		return db.User{}, err
+	}
+
 	user, err := s.userRepo.CreateUser(ctx, repositories.CreateUserParams{
-		Username: username,
-		Email:    email,
+		Username:     username,
+		Email:        email,
+		PasswordHash: passwordHash,
 	})
 	if err != nil {
 		s.logger.Error("Service: Failed to create user via repository", zap.Error(err), zap.String("username", username))
+		return db.User{}, fmt.Errorf("could not create user: %w", err)
 	}
 	return user, nil
 }
 
-func (s *UserService) CreateUserTX(ctx context.Context, username, email string) (db.User, db.Article, error) {
+func (s *UserService) CreateUserTX(ctx context.Context, username, email, password string) (_ db.User, _ db.Article, err error) {
+	ctx, span := startSpan(ctx, "UserService.CreateUserTX")
+	defer func() { endSpan(span, err) }()
+
+	passwordHash, err := hashPassword(password)
+	if err != nil {
+		return db.User{}, db.Article{}, err
+	}
+
 	tx, err := s.dBConn.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
 	if err != nil {
 		s.logger.Error("Service: Failed to create transactions", zap.Error(err))
@@ -62,14 +97,20 @@ func (s *UserService) CreateUserTX(ctx context.Context, username, email string)
 
 	// Create user
 	user, err := txUserRepo.CreateUser(ctx, repositories.CreateUserParams{
-		Username: username,
-		Email:    email,
+		Username:     username,
+		Email:        email,
+		PasswordHash: passwordHash,
 	})
 	if err != nil {
 		s.logger.Error("Service: Failed to create user within transaction", zap.Error(err), zap.String("username", username))
 		return db.User{}, db.Article{}, fmt.Errorf("fail to create user in transaction: %w", err)
 	}
 
+	if err := txUserRepo.AssignUserRole(ctx, user.ID, auth.RoleReader); err != nil {
+		s.logger.Error("Service: Failed to assign default role within transaction", zap.Error(err), zap.String("user_id", user.ID.String()))
+		return db.User{}, db.Article{}, fmt.Errorf("fail to assign default role: %w", err)
+	}
+
 	article, err := txArcticleRepo.CreateArticle(ctx, repositories.CreateArticleParams{
 		Title:    fmt.Sprintf("Welcome %s", username),
 		Content:  fmt.Sprintf("Thank you for joining our platform, %s! This is your first article.", user.Username),
@@ -94,7 +135,10 @@ func (s *UserService) CreateUserTX(ctx context.Context, username, email string)
 }
 
 // GetUserByID retrieves a user by ID.
-func (s *UserService) GetUserByID(ctx context.Context, id uuid.UUID) (db.User, error) {
+func (s *UserService) GetUserByID(ctx context.Context, id uuid.UUID) (_ db.User, err error) {
+	ctx, span := startSpan(ctx, "UserService.GetUserByID", attribute.String("user.id", id.String()))
+	defer func() { endSpan(span, err) }()
+
 	user, err := s.userRepo.GetUserByID(ctx, id)
 	if err != nil {
 		s.logger.Error("Service: Failed to get user by ID via repository", zap.Error(err), zap.String("user_id", id.String()))
@@ -103,18 +147,68 @@ func (s *UserService) GetUserByID(ctx context.Context, id uuid.UUID) (db.User, e
 	return user, nil
 }
 
-// ListUsers lists all users.
-func (s *UserService) ListUsers(ctx context.Context) ([]db.User, error) {
-	users, err := s.userRepo.ListUsers(ctx)
+// Authenticate verifies email and password and returns the matching user.
+// Unknown emails and wrong passwords both return ErrInvalidCredentials after
+// the same amount of hashing work.
+func (s *UserService) Authenticate(ctx context.Context, email, password string) (_ db.User, err error) {
+	ctx, span := startSpan(ctx, "UserService.Authenticate")
+	defer func() { endSpan(span, err) }()
+
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		s.logger.Error("Service: Failed to get user by email via repository", zap.Error(err))
+		return db.User{}, fmt.Errorf("could not authenticate user: %w", err)
+	}
+
+	if !checkPassword(user.PasswordHash, password) {
+		return db.User{}, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// UserFilter narrows a list of users. Nil fields match every user.
+type UserFilter struct {
+	UsernamePrefix *string
+	EmailDomain    *string
+	CreatedAfter   *time.Time
+	CreatedBefore  *time.Time
+}
+
+// ListUsers returns one page of the users matching filter, newest first
+// unless the page asks for ascending order.
+func (s *UserService) ListUsers(ctx context.Context, filter UserFilter, page PageRequest) (_ Page[db.User], err error) {
+	ctx, span := startSpan(ctx, "UserService.ListUsers")
+	defer func() { endSpan(span, err) }()
+
+	after, err := decodeCursor(page.Cursor, page.Ascending)
+	if err != nil {
+		return Page[db.User]{}, err
+	}
+
+	limit := page.limit()
+	users, err := s.userRepo.ListUsers(ctx, repositories.ListUsersParams{
+		UsernamePrefix: filter.UsernamePrefix,
+		EmailDomain:    filter.EmailDomain,
+		CreatedAfter:   filter.CreatedAfter,
+		CreatedBefore:  filter.CreatedBefore,
+		After:          after,
+		Ascending:      page.Ascending,
+		Limit:          int32(limit + 1),
+	})
 	if err != nil {
 		s.logger.Error("Service: Failed to list users via repository", zap.Error(err))
-		return nil, fmt.Errorf("could not list users: %w", err)
+		return Page[db.User]{}, fmt.Errorf("could not list users: %w", err)
 	}
-	return users, nil
+	return newPage(users, limit, page.Ascending, func(u db.User) (time.Time, uuid.UUID) {
+		return u.CreatedAt.Time, u.ID
+	}), nil
 }
 
-// UpdateUser updates an existing user.
-func (s *UserService) UpdateUser(ctx context.Context, id uuid.UUID, username, email string) (db.User, error) {
+// UpdateUser updates an existing user. Nil username or email leaves the stored value untouched.
+func (s *UserService) UpdateUser(ctx context.Context, id uuid.UUID, username, email *string) (_ db.User, err error) {
+	ctx, span := startSpan(ctx, "UserService.UpdateUser", attribute.String("user.id", id.String()))
+	defer func() { endSpan(span, err) }()
+
 	user, err := s.userRepo.UpdateUser(ctx, repositories.UpdateUserParams{
 		ID:       id,
 		Username: username,
@@ -128,11 +222,104 @@ func (s *UserService) UpdateUser(ctx context.Context, id uuid.UUID, username, em
 }
 
 // DeleteUser deletes a user by ID.
-func (s *UserService) DeleteUser(ctx context.Context, id uuid.UUID) error {
-	err := s.userRepo.DeleteUser(ctx, id)
+func (s *UserService) DeleteUser(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := startSpan(ctx, "UserService.DeleteUser", attribute.String("user.id", id.String()))
+	defer func() { endSpan(span, err) }()
+
+	err = s.userRepo.DeleteUser(ctx, id)
 	if err != nil {
 		s.logger.Error("Service: Failed to delete user via repository", zap.Error(err), zap.String("user_id", id.String()))
 		return fmt.Errorf("could not delete user: %w", err)
 	}
 	return nil
 }
+
+// GetUserRoles returns the names of the roles assigned to a user.
+func (s *UserService) GetUserRoles(ctx context.Context, id uuid.UUID) (_ []string, err error) {
+	ctx, span := startSpan(ctx, "UserService.GetUserRoles", attribute.String("user.id", id.String()))
+	defer func() { endSpan(span, err) }()
+
+	roles, err := s.userRepo.ListUserRoles(ctx, id)
+	if err != nil {
+		s.logger.Error("Service: Failed to list user roles via repository", zap.Error(err), zap.String("user_id", id.String()))
+		return nil, fmt.Errorf("could not get user roles: %w", err)
+	}
+	return roles, nil
+}
+
+// AssignRole grants role to a user. Assigning a role the user already has is a no-op.
+func (s *UserService) AssignRole(ctx context.Context, id uuid.UUID, role string) (err error) {
+	ctx, span := startSpan(ctx, "UserService.AssignRole", attribute.String("user.id", id.String()))
+	defer func() { endSpan(span, err) }()
+
+	err = s.userRepo.AssignUserRole(ctx, id, role)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation {
+			if pgErr.ConstraintName == "fk_user_role_user" {
+				return fmt.Errorf("could not assign role: %w", pgx.ErrNoRows)
+			}
+			return ErrUnknownRole
+		}
+		s.logger.Error("Service: Failed to assign user role via repository", zap.Error(err), zap.String("user_id", id.String()), zap.String("role", role))
+		return fmt.Errorf("could not assign role: %w", err)
+	}
+	s.logger.Info("Service: Role assigned", zap.String("user_id", id.String()), zap.String("role", role))
+	return nil
+}
+
+// RemoveRole revokes role from a user.
+func (s *UserService) RemoveRole(ctx context.Context, id uuid.UUID, role string) (err error) {
+	ctx, span := startSpan(ctx, "UserService.RemoveRole", attribute.String("user.id", id.String()))
+	defer func() { endSpan(span, err) }()
+
+	if err := s.userRepo.RemoveUserRole(ctx, id, role); err != nil {
+		s.logger.Error("Service: Failed to remove user role via repository", zap.Error(err), zap.String("user_id", id.String()), zap.String("role", role))
+		return fmt.Errorf("could not remove role: %w", err)
+	}
+	s.logger.Info("Service: Role removed", zap.String("user_id", id.String()), zap.String("role", role))
+	return nil
+}
+
+// SetPlan changes the plan of a user. Access tokens carry the plan, so the
+// change applies to the user's sessions once their token is refreshed and to
+// their API keys immediately.
+func (s *UserService) SetPlan(ctx context.Context, id uuid.UUID, plan string) (_ db.User, err error) {
+	ctx, span := startSpan(ctx, "UserService.SetPlan", attribute.String("user.id", id.String()))
+	defer func() { endSpan(span, err) }()
+
+	user, err := s.userRepo.UpdateUserPlan(ctx, id, plan)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgCheckViolation {
+			return db.User{}, ErrUnknownPlan
+		}
+		s.logger.Error("Service: Failed to update user plan via repository", zap.Error(err), zap.String("user_id", id.String()), zap.String("plan", plan))
+		return db.User{}, fmt.Errorf("could not set plan: %w", err)
+	}
+	s.logger.Info("Service: Plan set", zap.String("user_id", id.String()), zap.String("plan", plan))
+	return user, nil
+}
+
+// SetPassword replaces the password of a user. It is how an admin gives
+// access back to a user who has no password, such as one created before
+// passwords were stored, or who has lost theirs.
+func (s *UserService) SetPassword(ctx context.Context, id uuid.UUID, password string) (err error) {
+	ctx, span := startSpan(ctx, "UserService.SetPassword", attribute.String("user.id", id.String()))
+	defer func() { endSpan(span, err) }()
+
+	passwordHash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	updated, err := s.userRepo.UpdateUserPassword(ctx, id, passwordHash)
+	if err != nil {
+		s.logger.Error("Service: Failed to update user password via repository", zap.Error(err), zap.String("user_id", id.String()))
+		return fmt.Errorf("could not set password: %w", err)
+	}
+	if !updated {
+		return fmt.Errorf("could not set password: %w", pgx.ErrNoRows)
+	}
+	s.logger.Info("Service: Password set", zap.String("user_id", id.String()))
+	return nil
+}
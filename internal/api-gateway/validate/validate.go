@@ -0,0 +1,178 @@
+// Package validate checks request structs against rules declared in their
+// `validate` struct tags, for example:
+//
+//	Username string `json:"username" validate:"required,min=3,max=32,pattern=username"`
+//
+// Supported rules are required, min and max (length in characters, or number
+// of elements for slices), email and pattern=<name>, where name is a regular
+// expression registered with RegisterPattern. Rules apply to string, *string
+// and []string fields; a nil pointer is only checked by required. Field names
+// in errors are taken from the json tag.
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// FieldError describes why one field failed validation.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// Errors is returned by Struct when one or more fields are invalid.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Field + ": " + fe.Message
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+var (
+	patternsMu sync.RWMutex
+	patterns   = map[string]*regexp.Regexp{
+		"username": regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`),
+	}
+)
+
+// RegisterPattern makes re available to the pattern rule under name.
+// Patterns are named so that expressions never need escaping inside tags.
+func RegisterPattern(name string, re *regexp.Regexp) {
+	patternsMu.Lock()
+	defer patternsMu.Unlock()
+	patterns[name] = re
+}
+
+// Struct validates the struct v points to. It returns Errors listing every
+// invalid field, or nil. Malformed rules are programming errors and panic.
+func Struct(v any) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validate: %T is not a struct", v))
+	}
+
+	var errs Errors
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		tag := sf.Tag.Get("validate")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		if msg := checkField(rv.Field(i), strings.Split(tag, ",")); msg != "" {
+			errs = append(errs, FieldError{Field: fieldName(sf), Message: msg})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// checkField returns the message of the first rule fv breaks, or "".
+func checkField(fv reflect.Value, rules []string) string {
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			if contains(rules, "required") {
+				return "is required"
+			}
+			return ""
+		}
+		fv = fv.Elem()
+	}
+
+	for _, rule := range rules {
+		name, arg, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			if fv.IsZero() || (fv.Kind() == reflect.String && strings.TrimSpace(fv.String()) == "") {
+				return "is required"
+			}
+		case "min":
+			if n := length(fv); n < atoi(rule, arg) {
+				return fmt.Sprintf("must be at least %s %s long", arg, unit(fv))
+			}
+		case "max":
+			if n := length(fv); n > atoi(rule, arg) {
+				return fmt.Sprintf("must be at most %s %s long", arg, unit(fv))
+			}
+		case "email":
+			if s := fv.String(); s != "" && !isEmail(s) {
+				return "must be a valid email address"
+			}
+		case "pattern":
+			patternsMu.RLock()
+			re, ok := patterns[arg]
+			patternsMu.RUnlock()
+			if !ok {
+				panic(fmt.Sprintf("validate: unknown pattern %q", arg))
+			}
+			if s := fv.String(); s != "" && !re.MatchString(s) {
+				return "has an invalid format"
+			}
+		default:
+			panic(fmt.Sprintf("validate: unknown rule %q", rule))
+		}
+	}
+	return ""
+}
+
+// isEmail accepts a bare address such as user@example.com, rejecting display
+// names and addresses without a dot in the domain.
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s {
+		return false
+	}
+	_, domain, _ := strings.Cut(s, "@")
+	return strings.Contains(domain, ".")
+}
+
+func length(fv reflect.Value) int {
+	if fv.Kind() == reflect.String {
+		return utf8.RuneCountInString(fv.String())
+	}
+	return fv.Len()
+}
+
+func unit(fv reflect.Value) string {
+	if fv.Kind() == reflect.String {
+		return "characters"
+	}
+	return "items"
+}
+
+func atoi(rule, arg string) int {
+	n, err := strconv.Atoi(arg)
+	if err != nil {
+		panic(fmt.Sprintf("validate: rule %q needs an integer argument", rule))
+	}
+	return n
+}
+
+func contains(rules []string, rule string) bool {
+	for _, r := range rules {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldName(sf reflect.StructField) string {
+	if name, _, _ := strings.Cut(sf.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	return sf.Name
+}
@@ -5,8 +5,10 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
 )
 
@@ -14,13 +16,30 @@ type Config struct {
 	App       AppConfig       `mapstructure:"APP"`
 	Log       LogConfig       `mapstructure:"LOG"`
 	Database  DatabaseConfig  `mapstructure:"DATABASE"`
+	Redis     RedisConfig     `mapstructure:"REDIS"`
 	JWT       JWTConfig       `mapstructure:"JWT"`
 	RateLimit RateLimitConfig `mapstructure:"RATE_LIMIT"`
+	Tracing   TracingConfig   `mapstructure:"TRACING"`
+	Health    HealthConfig    `mapstructure:"HEALTH"`
+	Secrets   SecretsConfig   `mapstructure:"SECRETS"`
+
+	// file is the config file that was read, empty if none was found.
+	file string
 }
 
 type AppConfig struct {
-	Port                   int           `mapstructure:"PORT"`
+	Port int `mapstructure:"PORT"`
+	// AdminPort serves metrics, pprof and the /admin endpoints. It must not
+	// be reachable from the public network.
+	AdminPort int `mapstructure:"ADMIN_PORT"`
+	// AdminHost is the address the admin server listens on, loopback by
+	// default. Use 0.0.0.0 only behind a network policy that keeps the port
+	// private.
+	AdminHost              string        `mapstructure:"ADMIN_HOST"`
 	GracefulShutdownPeriod time.Duration `mapstructure:"GRACEFUL_SHUTDOWN_PERIOD"`
+	// ReadinessDrainPeriod is how long /readyz fails before shutdown starts,
+	// giving load balancers time to stop routing traffic to the instance.
+	ReadinessDrainPeriod time.Duration `mapstructure:"READINESS_DRAIN_PERIOD"`
 }
 
 type LogConfig struct {
@@ -33,38 +52,179 @@ type DatabaseConfig struct {
 	MaxConnections int    `mapstructure:"MAX_CONNECTIONS"`
 }
 
+type RedisConfig struct {
+	URL string `mapstructure:"URL"`
+}
+
 type JWTConfig struct {
-	Secret             string        `mapstructure:"SECERT"`
-	ExpirationDuration time.Duration `mapstructure:"EXPIRATION_DURATION"`
+	// Algorithm is one of HS256, RS256, ES256 or EdDSA. HS256 signs with Secret,
+	// the others load their key from PrivateKeyFile.
+	// These describe a single signing key and are ignored when Keys is set.
+	Algorithm      string `mapstructure:"ALGORITHM"`
+	KeyID          string `mapstructure:"KEY_ID"`
+	PrivateKeyFile string `mapstructure:"PRIVATE_KEY_FILE"`
+	Secret         string `mapstructure:"SECRET"`
+	// Keys is the signing keyring used for rotation without downtime.
+	Keys []JWTKeyConfig `mapstructure:"KEYS"`
+	// ExpirationDuration is the lifetime of access tokens.
+	ExpirationDuration        time.Duration `mapstructure:"EXPIRATION_DURATION"`
+	RefreshExpirationDuration time.Duration `mapstructure:"REFRESH_EXPIRATION_DURATION"`
+}
+
+// JWTKeyConfig is one key of the JWT keyring. The newest active key with
+// private material signs new tokens; every key that has not retired verifies.
+type JWTKeyConfig struct {
+	ID        string `mapstructure:"ID"`
+	Algorithm string `mapstructure:"ALGORITHM"`
+	// Exactly one of Secret (HMAC), PrivateKeyFile or PublicKeyFile is used.
+	// Keys with only a PublicKeyFile can verify but never sign.
+	Secret         string    `mapstructure:"SECRET"`
+	PrivateKeyFile string    `mapstructure:"PRIVATE_KEY_FILE"`
+	PublicKeyFile  string    `mapstructure:"PUBLIC_KEY_FILE"`
+	ActivatesAt    time.Time `mapstructure:"ACTIVATES_AT"`
+	RetiresAt      time.Time `mapstructure:"RETIRES_AT"`
 }
 
 type RateLimitConfig struct {
+	// Backend is "memory" for per-replica limits or "redis" for limits shared
+	// by every replica. The redis backend falls back to memory while Redis is
+	// unavailable.
+	Backend string `mapstructure:"BACKEND"`
+	// LimitInterval, Burst and KeyBy form the "default" policy.
 	LimitInterval time.Duration `mapstructure:"LIMIT_INTERVAL"`
 	Burst         int           `mapstructure:"BURST"`
+	// KeyBy is what a separate limit is kept for: "ip", "user" or "api_key".
+	// Callers without a principal are always limited by IP.
+	KeyBy string `mapstructure:"KEY_BY"`
+	// ClientIPHeader names a header, such as X-Forwarded-For, that trusted
+	// proxies append the address of their peer to. The client IP is read
+	// from it instead of the peer address.
+	ClientIPHeader string `mapstructure:"CLIENT_IP_HEADER"`
+	// TrustedProxyHops is the number of trusted proxies in front of the app.
+	// The client IP is the entry that many places from the right of
+	// ClientIPHeader, since entries to the left of it are sent by the client
+	// and can be forged.
+	TrustedProxyHops int `mapstructure:"TRUSTED_PROXY_HOPS"`
+	// MaxClients bounds the number of clients tracked in memory per policy;
+	// the least recently seen client is dropped beyond it.
+	MaxClients int `mapstructure:"MAX_CLIENTS"`
+	// IdleTimeout drops in-memory clients not seen for this long.
+	IdleTimeout time.Duration `mapstructure:"IDLE_TIMEOUT"`
+	// Policies are named limits used by route groups and Routes. Fields left
+	// zero inherit from the default policy, which can itself be adjusted
+	// with a policy named "default".
+	Policies map[string]RateLimitPolicyConfig `mapstructure:"POLICIES"`
+	// Routes attaches a policy to a single route, keyed by the pattern it is
+	// registered with on the v1 mux, such as "POST /users". Patterns are
+	// matched case-insensitively.
+	Routes map[string]string `mapstructure:"ROUTES"`
+}
+
+type RateLimitPolicyConfig struct {
+	LimitInterval time.Duration `mapstructure:"LIMIT_INTERVAL"`
+	Burst         int           `mapstructure:"BURST"`
+	KeyBy         string        `mapstructure:"KEY_BY"`
+	// DailyQuota and MonthlyQuota cap requests per client per UTC calendar
+	// day and month. Zero inherits and a negative value means unlimited.
+	DailyQuota   int64 `mapstructure:"DAILY_QUOTA"`
+	MonthlyQuota int64 `mapstructure:"MONTHLY_QUOTA"`
+	// Plans overrides the policy for principals on a plan, such as "pro".
+	Plans map[string]RateLimitPlanConfig `mapstructure:"PLANS"`
+}
+
+// RateLimitPlanConfig overrides a policy for one plan. Fields left zero
+// inherit from the policy.
+type RateLimitPlanConfig struct {
+	LimitInterval time.Duration `mapstructure:"LIMIT_INTERVAL"`
+	Burst         int           `mapstructure:"BURST"`
+	DailyQuota    int64         `mapstructure:"DAILY_QUOTA"`
+	MonthlyQuota  int64         `mapstructure:"MONTHLY_QUOTA"`
+}
+
+type HealthConfig struct {
+	// CheckTimeout bounds each readiness check.
+	CheckTimeout time.Duration `mapstructure:"CHECK_TIMEOUT"`
+	// CacheTTL is how long readiness results are reused between probes.
+	CacheTTL time.Duration `mapstructure:"CACHE_TTL"`
+}
+
+type TracingConfig struct {
+	// Exporter is "otlp", "stdout" or "none". With "none" spans are not
+	// recorded, but incoming trace context is still propagated.
+	Exporter    string `mapstructure:"EXPORTER"`
+	ServiceName string `mapstructure:"SERVICE_NAME"`
+	// OTLPEndpoint is the host:port of an OTLP/HTTP collector.
+	OTLPEndpoint string `mapstructure:"OTLP_ENDPOINT"`
+	OTLPInsecure bool   `mapstructure:"OTLP_INSECURE"`
+	// SampleRatio is the fraction of new traces recorded. Requests that
+	// arrive with a sampled parent are always recorded.
+	SampleRatio float64 `mapstructure:"SAMPLE_RATIO"`
+}
+
+// SecretsConfig configures where ${secret:name} references are looked up.
+// Without it they are served by a provider passed to RegisterSecretProvider.
+type SecretsConfig struct {
+	// LocalFile is a JSON object of secret names to values, standing in for
+	// a secret manager in development.
+	LocalFile string `mapstructure:"LOCAL_FILE"`
 }
 
 func LoadConfig() *Config {
-	viper.AddConfigPath(".")
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
+	config, err := ReadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	return config
+}
+
+// ReadConfig reads the config file and environment variables, resolves
+// secret references and validates the result. Unlike LoadConfig it returns
+// errors, so it can be used to reload a running app. Every call reads into
+// a fresh viper instance, so a reload never shares state with an earlier
+// read.
+func ReadConfig() (*Config, error) {
+	v := viper.New()
+	v.AddConfigPath(".")
+	v.SetConfigName("config")
+	v.SetConfigType("yaml")
 
-	viper.SetEnvPrefix("GOSERVE")
-	viper.AutomaticEnv()
+	// Nested keys map to environment variables with dots replaced, so
+	// database.url is read from GOSERVE_DATABASE_URL.
+	v.SetEnvPrefix("GOSERVE")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	setDefaults(v)
 
-	if err := viper.ReadInConfig(); err != nil {
+	if err := v.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
 			fmt.Fprintf(os.Stdout, "Config file not found, using defaults and environment variables.\n")
 		} else {
-			fmt.Fprintf(os.Stderr, "Error reading config file: %s\n", err)
-			os.Exit(1)
+			return nil, fmt.Errorf("error reading config file: %w", err)
 		}
 	}
 
+	if err := bindSecretFiles(v); err != nil {
+		return nil, err
+	}
+
 	var config Config
-	if err := viper.Unmarshal(&config); err != nil {
-		fmt.Fprintf(os.Stderr, "Unable to decode into struct, %v\n", err)
-		os.Exit(1)
+	decodeHook := viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		mapstructure.StringToTimeHookFunc(time.RFC3339),
+	))
+	if err := v.Unmarshal(&config, decodeHook); err != nil {
+		return nil, fmt.Errorf("unable to decode into struct: %w", err)
+	}
+	config.file = v.ConfigFileUsed()
+	if err := resolveSecrets(&config); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
 	}
 
-	return &config
+	return &config, nil
 }
@@ -0,0 +1,49 @@
+package config
+
+import "github.com/spf13/viper"
+
+// setDefaults registers a default for every setting. Besides filling gaps in
+// config.yaml, a registered key is what lets viper bind its environment
+// variable, such as GOSERVE_JWT_SECRET, when the file does not set it.
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("app.port", 8080)
+	v.SetDefault("app.admin_port", 9090)
+	v.SetDefault("app.admin_host", "127.0.0.1")
+	v.SetDefault("app.graceful_shutdown_period", "5s")
+	v.SetDefault("app.readiness_drain_period", "3s")
+
+	v.SetDefault("log.level", "info")
+	v.SetDefault("log.encoding", "json")
+
+	v.SetDefault("database.url", "")
+	v.SetDefault("database.max_connections", 10)
+
+	v.SetDefault("redis.url", "redis://localhost:6379/0")
+
+	v.SetDefault("jwt.algorithm", "HS256")
+	v.SetDefault("jwt.key_id", "")
+	v.SetDefault("jwt.private_key_file", "")
+	v.SetDefault("jwt.secret", "")
+	v.SetDefault("jwt.expiration_duration", "10m")
+	v.SetDefault("jwt.refresh_expiration_duration", "720h")
+
+	v.SetDefault("rate_limit.backend", "memory")
+	v.SetDefault("rate_limit.limit_interval", "10s")
+	v.SetDefault("rate_limit.burst", 2)
+	v.SetDefault("rate_limit.key_by", "user")
+	v.SetDefault("rate_limit.client_ip_header", "")
+	v.SetDefault("rate_limit.trusted_proxy_hops", 1)
+	v.SetDefault("rate_limit.max_clients", 10000)
+	v.SetDefault("rate_limit.idle_timeout", "10m")
+
+	v.SetDefault("health.check_timeout", "2s")
+	v.SetDefault("health.cache_ttl", "1s")
+
+	v.SetDefault("tracing.exporter", "none")
+	v.SetDefault("tracing.service_name", "go-serve")
+	v.SetDefault("tracing.otlp_endpoint", "localhost:4318")
+	v.SetDefault("tracing.otlp_insecure", false)
+	v.SetDefault("tracing.sample_ratio", 1.0)
+
+	v.SetDefault("secrets.local_file", "")
+}
@@ -0,0 +1,48 @@
+package config
+
+import "net/url"
+
+// redacted replaces secret values in output.
+const redacted = "REDACTED"
+
+// Redacted returns a copy of c that is safe to log or serve: secrets are
+// replaced and passwords are removed from connection URLs.
+func (c Config) Redacted() Config {
+	c.Database.URL = redactURL(c.Database.URL)
+	c.Redis.URL = redactURL(c.Redis.URL)
+	c.JWT.Secret = redactSecret(c.JWT.Secret)
+
+	keys := make([]JWTKeyConfig, len(c.JWT.Keys))
+	for i, key := range c.JWT.Keys {
+		key.Secret = redactSecret(key.Secret)
+		keys[i] = key
+	}
+	c.JWT.Keys = keys
+
+	return c
+}
+
+func redactSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// redactURL masks the password of u, whether in the user info or a
+// password query parameter. Values that are not URLs, such as key/value
+// connection strings, are hidden entirely since they could contain anything.
+func redactURL(u string) string {
+	if u == "" {
+		return ""
+	}
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Scheme == "" {
+		return redacted
+	}
+	if query := parsed.Query(); query.Has("password") {
+		query.Set("password", redacted)
+		parsed.RawQuery = query.Encode()
+	}
+	return parsed.Redacted()
+}
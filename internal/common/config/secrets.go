@@ -0,0 +1,214 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// ErrSecretNotFound is returned by a SecretProvider that has no secret
+// under the requested name.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretProvider looks up secrets referenced from configuration values as
+// ${scheme:name}. Implementations backed by a secret manager such as Vault
+// are plugged in with RegisterSecretProvider.
+type SecretProvider interface {
+	// Secret returns the value stored under name.
+	Secret(ctx context.Context, name string) (string, error)
+}
+
+// SecretProviderFunc adapts a function to a SecretProvider.
+type SecretProviderFunc func(ctx context.Context, name string) (string, error)
+
+func (f SecretProviderFunc) Secret(ctx context.Context, name string) (string, error) {
+	return f(ctx, name)
+}
+
+// localSecretScheme is the scheme served by SecretsConfig.LocalFile.
+const localSecretScheme = "secret"
+
+// secretResolveTimeout bounds the lookup of all references in one read.
+const secretResolveTimeout = 10 * time.Second
+
+// secretReference matches ${scheme:name}. References may be embedded in a
+// longer value, such as the password of a connection URL.
+var secretReference = regexp.MustCompile(`\$\{([a-z][a-z0-9_]*):([^}]+)\}`)
+
+var (
+	secretProvidersMu sync.RWMutex
+	secretProviders   = map[string]SecretProvider{
+		"env":  SecretProviderFunc(envSecret),
+		"file": SecretProviderFunc(fileSecret),
+	}
+)
+
+// RegisterSecretProvider serves references with scheme from p. It must be
+// called before the configuration is loaded and replaces any provider
+// already registered for scheme.
+func RegisterSecretProvider(scheme string, p SecretProvider) {
+	secretProvidersMu.Lock()
+	defer secretProvidersMu.Unlock()
+	secretProviders[scheme] = p
+}
+
+func envSecret(_ context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return value, nil
+}
+
+func fileSecret(_ context.Context, path string) (string, error) {
+	return readSecretFile(path)
+}
+
+// readSecretFile reads a secret mounted as a file. The trailing newline most
+// editors and `echo` add is not part of the secret.
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// LocalSecretProvider serves secrets from a JSON object of names to values.
+// It stands in for a secret manager in development and tests.
+type LocalSecretProvider struct {
+	secrets map[string]string
+}
+
+// NewLocalSecretProvider loads the secrets in the JSON file at path.
+func NewLocalSecretProvider(path string) (*LocalSecretProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read secrets file: %w", err)
+	}
+	var secrets map[string]string
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		return nil, fmt.Errorf("unable to decode secrets file %s: %w", path, err)
+	}
+	return &LocalSecretProvider{secrets: secrets}, nil
+}
+
+func (p *LocalSecretProvider) Secret(_ context.Context, name string) (string, error) {
+	value, ok := p.secrets[name]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return value, nil
+}
+
+// bindSecretFiles sets every key from the file named by its environment
+// variable with a _FILE suffix, such as GOSERVE_JWT_SECRET_FILE for
+// jwt.secret, as used for Docker and Kubernetes secrets.
+func bindSecretFiles(v *viper.Viper) error {
+	for _, key := range v.AllKeys() {
+		env := "GOSERVE_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_")) + "_FILE"
+		path, ok := os.LookupEnv(env)
+		if !ok || path == "" {
+			continue
+		}
+		value, err := readSecretFile(path)
+		if err != nil {
+			return fmt.Errorf("unable to read %s: %w", env, err)
+		}
+		v.Set(key, value)
+	}
+	return nil
+}
+
+// resolveSecrets replaces the secret references in every string of c.
+func resolveSecrets(c *Config) error {
+	secretProvidersMu.RLock()
+	providers := make(map[string]SecretProvider, len(secretProviders)+1)
+	for scheme, p := range secretProviders {
+		providers[scheme] = p
+	}
+	secretProvidersMu.RUnlock()
+
+	if c.Secrets.LocalFile != "" {
+		local, err := NewLocalSecretProvider(c.Secrets.LocalFile)
+		if err != nil {
+			return err
+		}
+		providers[localSecretScheme] = local
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretResolveTimeout)
+	defer cancel()
+
+	r := &secretResolver{ctx: ctx, providers: providers}
+	r.walk(reflect.ValueOf(c).Elem())
+	return r.err
+}
+
+// secretResolver expands references in place, keeping the first error.
+type secretResolver struct {
+	ctx       context.Context
+	providers map[string]SecretProvider
+	err       error
+}
+
+func (r *secretResolver) walk(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(r.expand(v.String()))
+	case reflect.Struct:
+		for i := range v.NumField() {
+			if v.Type().Field(i).IsExported() {
+				r.walk(v.Field(i))
+			}
+		}
+	case reflect.Slice:
+		for i := range v.Len() {
+			r.walk(v.Index(i))
+		}
+	case reflect.Map:
+		// Map values are not addressable, so each is copied, expanded and
+		// stored back.
+		iter := v.MapRange()
+		for iter.Next() {
+			value := reflect.New(iter.Value().Type()).Elem()
+			value.Set(iter.Value())
+			r.walk(value)
+			v.SetMapIndex(iter.Key(), value)
+		}
+	}
+}
+
+func (r *secretResolver) expand(s string) string {
+	if r.err != nil || !strings.Contains(s, "${") {
+		return s
+	}
+	return secretReference.ReplaceAllStringFunc(s, func(ref string) string {
+		if r.err != nil {
+			return ref
+		}
+		match := secretReference.FindStringSubmatch(ref)
+		scheme, name := match[1], match[2]
+
+		provider, ok := r.providers[scheme]
+		if !ok {
+			r.err = fmt.Errorf("unable to resolve %s: no secret provider for %q", ref, scheme)
+			return ref
+		}
+		value, err := provider.Secret(r.ctx, name)
+		if err != nil {
+			r.err = fmt.Errorf("unable to resolve %s: %w", ref, err)
+			return ref
+		}
+		return value
+	})
+}
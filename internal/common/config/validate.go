@@ -0,0 +1,212 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// minHMACSecretLength is the shortest HMAC secret accepted, matching the
+// 256-bit output of HS256.
+const minHMACSecretLength = 32
+
+var (
+	logLevels         = []string{"debug", "info", "warn", "error", "dpanic", "panic", "fatal"}
+	logEncodings      = []string{"json", "console"}
+	jwtAlgorithms     = []string{"HS256", "RS256", "ES256", "EdDSA"}
+	rateLimitBackends = []string{"memory", "redis"}
+	rateLimitKeyBys   = []string{"ip", "user", "api_key"}
+	tracingExporters  = []string{"otlp", "stdout", "none"}
+)
+
+// ValidationError lists every problem found in a configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// validator collects problems under the config key they concern.
+type validator struct {
+	problems []string
+}
+
+func (v *validator) addf(key, format string, args ...any) {
+	v.problems = append(v.problems, key+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *validator) port(key string, port int) {
+	if port < 1 || port > 65535 {
+		v.addf(key, "must be between 1 and 65535, got %d", port)
+	}
+}
+
+func (v *validator) positive(key string, d time.Duration) {
+	if d <= 0 {
+		v.addf(key, "must be a positive duration, got %s", d)
+	}
+}
+
+func (v *validator) nonNegative(key string, d time.Duration) {
+	if d < 0 {
+		v.addf(key, "must not be negative, got %s", d)
+	}
+}
+
+func (v *validator) oneOf(key, value string, allowed []string) {
+	if !slices.Contains(allowed, value) {
+		v.addf(key, "must be one of %s, got %q", strings.Join(allowed, ", "), value)
+	}
+}
+
+func (v *validator) url(key, value string, schemes ...string) {
+	u, err := url.Parse(value)
+	if err != nil {
+		// url.Error repeats the URL, which may hold a password.
+		v.addf(key, "must be a valid URL")
+		return
+	}
+	if !slices.Contains(schemes, u.Scheme) {
+		v.addf(key, "must use one of the schemes %s", strings.Join(schemes, ", "))
+	}
+}
+
+// Validate checks every section of c and reports all problems at once as a
+// *ValidationError.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.port("app.port", c.App.Port)
+	v.port("app.admin_port", c.App.AdminPort)
+	if c.App.AdminPort == c.App.Port {
+		v.addf("app.admin_port", "must differ from app.port")
+	}
+	if c.App.AdminHost == "" {
+		v.addf("app.admin_host", "is required; use 0.0.0.0 to listen on every interface")
+	}
+	v.positive("app.graceful_shutdown_period", c.App.GracefulShutdownPeriod)
+	v.nonNegative("app.readiness_drain_period", c.App.ReadinessDrainPeriod)
+
+	v.oneOf("log.level", strings.ToLower(c.Log.Level), logLevels)
+	v.oneOf("log.encoding", c.Log.Encoding, logEncodings)
+
+	if c.Database.URL == "" {
+		v.addf("database.url", "is required")
+	} else if _, err := pgconn.ParseConfig(c.Database.URL); err != nil {
+		// The parse error may echo the URL and with it the password.
+		v.addf("database.url", "must be a valid PostgreSQL connection string")
+	}
+	if c.Database.MaxConnections < 1 {
+		v.addf("database.max_connections", "must be at least 1, got %d", c.Database.MaxConnections)
+	}
+
+	if c.RateLimit.Backend == "redis" {
+		v.url("redis.url", c.Redis.URL, "redis", "rediss")
+	}
+
+	c.JWT.validate(v)
+	c.RateLimit.validate(v)
+
+	v.nonNegative("health.check_timeout", c.Health.CheckTimeout)
+	v.nonNegative("health.cache_ttl", c.Health.CacheTTL)
+
+	v.oneOf("tracing.exporter", c.Tracing.Exporter, tracingExporters)
+	if c.Tracing.Exporter == "otlp" && c.Tracing.OTLPEndpoint == "" {
+		v.addf("tracing.otlp_endpoint", "is required for the otlp exporter")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		v.addf("tracing.sample_ratio", "must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+	}
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+func (c *JWTConfig) validate(v *validator) {
+	v.positive("jwt.expiration_duration", c.ExpirationDuration)
+	v.positive("jwt.refresh_expiration_duration", c.RefreshExpirationDuration)
+
+	if len(c.Keys) == 0 {
+		v.oneOf("jwt.algorithm", c.Algorithm, jwtAlgorithms)
+		if c.Algorithm == "HS256" {
+			if len(c.Secret) < minHMACSecretLength {
+				v.addf("jwt.secret", "must be at least %d characters for HS256", minHMACSecretLength)
+			}
+		} else if c.PrivateKeyFile == "" {
+			v.addf("jwt.private_key_file", "is required for %s", c.Algorithm)
+		}
+		return
+	}
+
+	seen := make(map[string]bool, len(c.Keys))
+	for i, key := range c.Keys {
+		prefix := fmt.Sprintf("jwt.keys[%d]", i)
+		if key.ID == "" {
+			v.addf(prefix+".id", "is required")
+		} else if seen[key.ID] {
+			v.addf(prefix+".id", "duplicates key %q", key.ID)
+		}
+		seen[key.ID] = true
+
+		v.oneOf(prefix+".algorithm", key.Algorithm, jwtAlgorithms)
+		if key.Algorithm == "HS256" {
+			if len(key.Secret) < minHMACSecretLength {
+				v.addf(prefix+".secret", "must be at least %d characters for HS256", minHMACSecretLength)
+			}
+		} else if key.PrivateKeyFile == "" && key.PublicKeyFile == "" {
+			v.addf(prefix, "needs a private_key_file or public_key_file for %s", key.Algorithm)
+		}
+		if !key.ActivatesAt.IsZero() && !key.RetiresAt.IsZero() && !key.RetiresAt.After(key.ActivatesAt) {
+			v.addf(prefix+".retires_at", "must be after activates_at")
+		}
+	}
+}
+
+func (c *RateLimitConfig) validate(v *validator) {
+	v.oneOf("rate_limit.backend", c.Backend, rateLimitBackends)
+	v.positive("rate_limit.limit_interval", c.LimitInterval)
+	if c.Burst < 1 {
+		v.addf("rate_limit.burst", "must be at least 1, got %d", c.Burst)
+	}
+	v.oneOf("rate_limit.key_by", c.KeyBy, rateLimitKeyBys)
+	if c.ClientIPHeader != "" && c.TrustedProxyHops < 1 {
+		v.addf("rate_limit.trusted_proxy_hops", "must be at least 1 when client_ip_header is set, got %d", c.TrustedProxyHops)
+	}
+	if c.MaxClients < 0 {
+		v.addf("rate_limit.max_clients", "must not be negative, got %d", c.MaxClients)
+	}
+	v.nonNegative("rate_limit.idle_timeout", c.IdleTimeout)
+
+	// Zero fields of policies and plans inherit, so only negative values and
+	// unknown names are errors here.
+	for name, policy := range c.Policies {
+		prefix := "rate_limit.policies." + name
+		v.nonNegative(prefix+".limit_interval", policy.LimitInterval)
+		if policy.Burst < 0 {
+			v.addf(prefix+".burst", "must not be negative, got %d", policy.Burst)
+		}
+		if policy.KeyBy != "" {
+			v.oneOf(prefix+".key_by", policy.KeyBy, rateLimitKeyBys)
+		}
+		for plan, tier := range policy.Plans {
+			planPrefix := prefix + ".plans." + plan
+			v.nonNegative(planPrefix+".limit_interval", tier.LimitInterval)
+			if tier.Burst < 0 {
+				v.addf(planPrefix+".burst", "must not be negative, got %d", tier.Burst)
+			}
+		}
+	}
+	for pattern, policy := range c.Routes {
+		if _, ok := c.Policies[policy]; !ok && policy != "default" {
+			v.addf("rate_limit.routes."+pattern, "uses unknown policy %q", policy)
+		}
+	}
+}
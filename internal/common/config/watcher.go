@@ -0,0 +1,184 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// Subscriber applies a reloaded configuration. It is called with the
+// configuration it replaces, so it can skip work when its section is
+// unchanged. A returned error is logged and leaves that part of the app
+// on its current settings. Since a failed reload is retried as a whole,
+// subscribers must be safe to call again with the same configuration.
+type Subscriber func(old, new *Config) error
+
+// restartRequired lists the settings that are only read at startup.
+// Changing them in a reload is reported but has no effect until restart.
+var restartRequired = []struct {
+	key   string
+	value func(*Config) any
+}{
+	{"app.port", func(c *Config) any { return c.App.Port }},
+	{"app.admin_port", func(c *Config) any { return c.App.AdminPort }},
+	{"app.admin_host", func(c *Config) any { return c.App.AdminHost }},
+	{"app.graceful_shutdown_period", func(c *Config) any { return c.App.GracefulShutdownPeriod }},
+	{"app.readiness_drain_period", func(c *Config) any { return c.App.ReadinessDrainPeriod }},
+	{"log.encoding", func(c *Config) any { return c.Log.Encoding }},
+	{"database.url", func(c *Config) any { return c.Database.URL }},
+	{"database.max_connections", func(c *Config) any { return c.Database.MaxConnections }},
+	{"redis.url", func(c *Config) any { return c.Redis.URL }},
+	{"jwt.expiration_duration", func(c *Config) any { return c.JWT.ExpirationDuration }},
+	{"jwt.refresh_expiration_duration", func(c *Config) any { return c.JWT.RefreshExpirationDuration }},
+	{"rate_limit.backend", func(c *Config) any { return c.RateLimit.Backend }},
+	{"rate_limit.max_clients", func(c *Config) any { return c.RateLimit.MaxClients }},
+	{"rate_limit.idle_timeout", func(c *Config) any { return c.RateLimit.IdleTimeout }},
+	{"health.check_timeout", func(c *Config) any { return c.Health.CheckTimeout }},
+	{"health.cache_ttl", func(c *Config) any { return c.Health.CacheTTL }},
+	{"tracing.exporter", func(c *Config) any { return c.Tracing.Exporter }},
+	{"tracing.service_name", func(c *Config) any { return c.Tracing.ServiceName }},
+	{"tracing.otlp_endpoint", func(c *Config) any { return c.Tracing.OTLPEndpoint }},
+	{"tracing.otlp_insecure", func(c *Config) any { return c.Tracing.OTLPInsecure }},
+	{"tracing.sample_ratio", func(c *Config) any { return c.Tracing.SampleRatio }},
+}
+
+// RestartRequired returns the keys of settings that differ between old and
+// new but are only applied at startup.
+func RestartRequired(old, new *Config) []string {
+	var keys []string
+	for _, setting := range restartRequired {
+		if setting.value(old) != setting.value(new) {
+			keys = append(keys, setting.key)
+		}
+	}
+	return keys
+}
+
+// Watcher holds the applied configuration and hands reloads to its
+// subscribers. A reload that fails to read or validate is logged and
+// dropped, so the app keeps running on the last good configuration. A
+// reload that some subscriber fails to apply does not become current, so
+// the next reload compares against what was applied before and retries.
+type Watcher struct {
+	mu          sync.Mutex
+	current     *Config
+	subscribers []Subscriber
+	logger      *zap.Logger
+}
+
+// NewWatcher creates a Watcher starting from the already loaded cfg.
+func NewWatcher(cfg *Config, logger *zap.Logger) *Watcher {
+	return &Watcher{
+		current: cfg,
+		logger:  logger,
+	}
+}
+
+// Current returns the last configuration every subscriber applied. It must
+// not be modified.
+func (w *Watcher) Current() *Config {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// Subscribe registers fn to be called after every successful reload, in
+// registration order.
+func (w *Watcher) Subscribe(fn Subscriber) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Reload reads the configuration again and applies it. It returns the read
+// or validation error when the new configuration is rejected, and the
+// errors of the subscribers that failed to apply it.
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	next, err := ReadConfig()
+	if err != nil {
+		w.logger.Error("Rejected configuration reload, keeping last good configuration", zap.Error(err))
+		return err
+	}
+
+	old := w.current
+	if keys := RestartRequired(old, next); len(keys) > 0 {
+		w.logger.Warn("Configuration changes need a restart to take effect", zap.Strings("keys", keys))
+	}
+	var errs []error
+	for _, fn := range w.subscribers {
+		if err := fn(old, next); err != nil {
+			w.logger.Error("Failed to apply reloaded configuration", zap.Error(err))
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		w.logger.Warn("Configuration partly applied, keeping previous configuration as current")
+		return errors.Join(errs...)
+	}
+	w.current = next
+	w.logger.Info("Configuration reloaded")
+	w.logger.Debug("Effective configuration", zap.Any("config", next.Redacted()))
+	return nil
+}
+
+// Watch reloads the configuration whenever the config file changes. It does
+// nothing when no config file was found. The directory is watched rather
+// than the file, so editors that replace the file on save and symlinked
+// files such as Kubernetes ConfigMaps are followed too.
+func (w *Watcher) Watch() {
+	file := w.Current().file
+	if file == "" {
+		w.logger.Warn("No config file in use, configuration is not watched")
+		return
+	}
+	file = filepath.Clean(file)
+
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		w.logger.Error("Failed to watch config file", zap.String("file", file), zap.Error(err))
+		return
+	}
+	if err := fsWatcher.Add(filepath.Dir(file)); err != nil {
+		fsWatcher.Close()
+		w.logger.Error("Failed to watch config file", zap.String("file", file), zap.Error(err))
+		return
+	}
+
+	target, _ := filepath.EvalSymlinks(file)
+	go func() {
+		defer fsWatcher.Close()
+		for {
+			select {
+			case e, ok := <-fsWatcher.Events:
+				if !ok {
+					return
+				}
+				// A symlinked file changes when its link target is swapped,
+				// which shows up as an event on another name in the directory.
+				current, _ := filepath.EvalSymlinks(file)
+				if current == "" {
+					// Removed, possibly to be replaced; wait for the new file.
+					continue
+				}
+				written := filepath.Clean(e.Name) == file && e.Has(fsnotify.Write|fsnotify.Create)
+				if !written && current == target {
+					continue
+				}
+				target = current
+				w.logger.Info("Config file changed", zap.String("file", e.Name), zap.String("op", e.Op.String()))
+				w.Reload()
+			case err, ok := <-fsWatcher.Errors:
+				if !ok {
+					return
+				}
+				w.logger.Error("Config file watch failed", zap.Error(err))
+			}
+		}
+	}()
+}
@@ -0,0 +1,147 @@
+// Package health runs the dependency checks behind the readiness probe.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+const (
+	defaultCheckTimeout = 2 * time.Second
+	defaultCacheTTL     = time.Second
+)
+
+// CheckFunc reports whether a dependency is usable. It must return promptly
+// once ctx is done.
+type CheckFunc func(ctx context.Context) error
+
+// ComponentReport is the outcome of one check.
+type ComponentReport struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration,omitempty"`
+}
+
+// Report is the outcome of every check. Status is up only if every
+// component is up.
+type Report struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentReport `json:"components"`
+	CheckedAt  time.Time                  `json:"checked_at"`
+}
+
+// Summary returns r with only the status of each component, leaving out
+// error messages that could reveal internal hosts or configuration.
+func (r Report) Summary() Report {
+	components := make(map[string]ComponentReport, len(r.Components))
+	for name, component := range r.Components {
+		components[name] = ComponentReport{Status: component.Status}
+	}
+	r.Components = components
+	return r
+}
+
+// Checker runs registered checks for readiness. Results are cached for a
+// short time so that frequent probes from several load balancers do not
+// turn into load on the dependencies.
+type Checker struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+	draining atomic.Bool
+
+	mu     sync.Mutex
+	checks map[string]CheckFunc
+	last   *Report
+}
+
+// NewChecker creates a Checker giving each check timeout to complete and
+// reusing results for cacheTTL. Zero values select defaults.
+func NewChecker(timeout, cacheTTL time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = defaultCheckTimeout
+	}
+	if cacheTTL <= 0 {
+		cacheTTL = defaultCacheTTL
+	}
+	return &Checker{
+		timeout:  timeout,
+		cacheTTL: cacheTTL,
+		checks:   make(map[string]CheckFunc),
+	}
+}
+
+// Register adds a check reported under name.
+func (c *Checker) Register(name string, check CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = check
+	c.last = nil
+}
+
+// Drain makes readiness fail from now on, so load balancers stop sending
+// traffic before the server shuts down.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Ready runs the checks, or returns the cached report if it is recent enough.
+func (c *Checker) Ready(ctx context.Context) Report {
+	if c.draining.Load() {
+		return Report{
+			Status:     StatusDown,
+			Components: map[string]ComponentReport{"server": {Status: StatusDown, Error: "shutting down"}},
+			CheckedAt:  time.Now(),
+		}
+	}
+
+	// Holding the lock while checking makes concurrent probes share one run.
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.last != nil && time.Since(c.last.CheckedAt) < c.cacheTTL {
+		return *c.last
+	}
+
+	report := Report{Status: StatusUp, Components: make(map[string]ComponentReport, len(c.checks)), CheckedAt: time.Now()}
+	var wg sync.WaitGroup
+	var reportMu sync.Mutex
+	for name, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			component := c.run(ctx, check)
+			reportMu.Lock()
+			defer reportMu.Unlock()
+			report.Components[name] = component
+			if component.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}()
+	}
+	wg.Wait()
+
+	c.last = &report
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check CheckFunc) ComponentReport {
+	// The result is shared with other probes, so it must not fail because
+	// the probe that triggered it went away.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	component := ComponentReport{Status: StatusUp, Duration: time.Since(start).String()}
+	if err != nil {
+		component.Status = StatusDown
+		component.Error = err.Error()
+	}
+	return component
+}
@@ -0,0 +1,254 @@
+// Package jwtkeys loads the keys used to sign and verify JWTs and
+// publishes the public halves as a JSON Web Key Set.
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/akshaysangma/go-serve/internal/common/config"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var (
+	// ErrUnknownKey is returned when a token references a kid that is not in the keyring.
+	ErrUnknownKey = errors.New("unknown signing key")
+	// ErrKeyRetired is returned when a token was signed by a key past its retirement time.
+	ErrKeyRetired = errors.New("signing key retired")
+	// ErrNoSigningKey is returned when no key with private material is currently active.
+	ErrNoSigningKey = errors.New("no active signing key")
+)
+
+// Key is a single JWT key. For HMAC keys Public holds the shared secret and
+// the key is never published. Private is nil for verification-only keys.
+type Key struct {
+	ID          string
+	Method      jwt.SigningMethod
+	Private     interface{}
+	Public      interface{}
+	ActivatesAt time.Time
+	// RetiresAt is zero for keys that never retire.
+	RetiresAt time.Time
+}
+
+func (k *Key) activeAt(t time.Time) bool {
+	return !t.Before(k.ActivatesAt) && !k.retiredAt(t)
+}
+
+func (k *Key) retiredAt(t time.Time) bool {
+	return !k.RetiresAt.IsZero() && !t.Before(k.RetiresAt)
+}
+
+// Keyring holds every configured key. The newest active key with private
+// material signs new tokens, and any key that has not retired verifies them,
+// so a signing key can be rotated without invalidating outstanding tokens.
+// It is safe for concurrent use and can be reloaded at runtime.
+type Keyring struct {
+	keys atomic.Pointer[[]*Key]
+}
+
+// NewKeyring builds a Keyring from config.
+func NewKeyring(config config.JWTConfig) (*Keyring, error) {
+	k := &Keyring{}
+	if err := k.Reload(config); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Reload replaces the keys with those in cfg. The current keys are kept
+// if any new key fails to load.
+func (k *Keyring) Reload(cfg config.JWTConfig) error {
+	keyConfigs := cfg.Keys
+	if len(keyConfigs) == 0 {
+		keyConfigs = []config.JWTKeyConfig{{
+			ID:             cfg.KeyID,
+			Algorithm:      cfg.Algorithm,
+			Secret:         cfg.Secret,
+			PrivateKeyFile: cfg.PrivateKeyFile,
+		}}
+	}
+
+	keys := make([]*Key, 0, len(keyConfigs))
+	seen := make(map[string]bool, len(keyConfigs))
+	for _, kc := range keyConfigs {
+		key, err := loadKey(kc)
+		if err != nil {
+			return fmt.Errorf("JWT key %q: %w", kc.ID, err)
+		}
+		if seen[key.ID] {
+			return fmt.Errorf("duplicate JWT key id %q", key.ID)
+		}
+		seen[key.ID] = true
+		keys = append(keys, key)
+	}
+
+	if signingKey(keys, time.Now()) == nil {
+		return ErrNoSigningKey
+	}
+
+	k.keys.Store(&keys)
+	return nil
+}
+
+// Sign signs claims with the active key and sets the kid header.
+func (k *Keyring) Sign(claims jwt.Claims) (string, error) {
+	key := signingKey(*k.keys.Load(), time.Now())
+	if key == nil {
+		return "", ErrNoSigningKey
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// Keyfunc resolves the verification key for t from its kid header. Tokens
+// without a kid are checked against the active signing key. The token's alg
+// must match the key's algorithm so a public key can never be used as an
+// HMAC secret.
+func (k *Keyring) Keyfunc(t *jwt.Token) (interface{}, error) {
+	keys, now := *k.keys.Load(), time.Now()
+
+	var key *Key
+	if kid, ok := t.Header["kid"].(string); ok {
+		if key = lookup(keys, kid); key == nil {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+		}
+		if key.retiredAt(now) {
+			return nil, fmt.Errorf("%w: %q", ErrKeyRetired, kid)
+		}
+	} else if key = signingKey(keys, now); key == nil {
+		return nil, ErrNoSigningKey
+	}
+
+	if t.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+	}
+	return key.Public, nil
+}
+
+// signingKey returns the most recently activated key that can sign at now.
+func signingKey(keys []*Key, now time.Time) *Key {
+	var active *Key
+	for _, key := range keys {
+		if key.Private == nil || !key.activeAt(now) {
+			continue
+		}
+		if active == nil || !key.ActivatesAt.Before(active.ActivatesAt) {
+			active = key
+		}
+	}
+	return active
+}
+
+func lookup(keys []*Key, kid string) *Key {
+	for _, key := range keys {
+		if key.ID == kid {
+			return key
+		}
+	}
+	return nil
+}
+
+func loadKey(kc config.JWTKeyConfig) (*Key, error) {
+	algorithm := kc.Algorithm
+	if algorithm == "" {
+		algorithm = jwt.SigningMethodHS256.Alg()
+	}
+	kid := kc.ID
+	if kid == "" {
+		kid = strings.ToLower(algorithm) + "-default"
+	}
+
+	method := jwt.GetSigningMethod(algorithm)
+	if method == nil {
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", algorithm)
+	}
+	key := &Key{ID: kid, Method: method, ActivatesAt: kc.ActivatesAt, RetiresAt: kc.RetiresAt}
+
+	if _, ok := method.(*jwt.SigningMethodHMAC); ok {
+		if kc.Secret == "" {
+			return nil, fmt.Errorf("JWT algorithm %s requires a secret", algorithm)
+		}
+		key.Private, key.Public = []byte(kc.Secret), []byte(kc.Secret)
+		return key, nil
+	}
+
+	if kc.PrivateKeyFile == "" {
+		if kc.PublicKeyFile == "" {
+			return nil, fmt.Errorf("JWT algorithm %s requires a private or public key file", algorithm)
+		}
+		return key, loadPublicKey(key, kc.PublicKeyFile)
+	}
+	return key, loadPrivateKey(key, kc.PrivateKeyFile)
+}
+
+func loadPrivateKey(key *Key, path string) error {
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read JWT private key: %w", err)
+	}
+
+	switch m := key.Method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+		if err != nil {
+			return fmt.Errorf("failed to parse RSA private key: %w", err)
+		}
+		key.Private, key.Public = private, &private.PublicKey
+	case *jwt.SigningMethodECDSA:
+		private, err := jwt.ParseECPrivateKeyFromPEM(pemBytes)
+		if err != nil {
+			return fmt.Errorf("failed to parse EC private key: %w", err)
+		}
+		if private.Curve.Params().BitSize != m.CurveBits {
+			return fmt.Errorf("EC key curve %s does not match %s", private.Curve.Params().Name, m.Alg())
+		}
+		key.Private, key.Public = private, &private.PublicKey
+	case *jwt.SigningMethodEd25519:
+		private, err := jwt.ParseEdPrivateKeyFromPEM(pemBytes)
+		if err != nil {
+			return fmt.Errorf("failed to parse Ed25519 private key: %w", err)
+		}
+		edPrivate, ok := private.(ed25519.PrivateKey)
+		if !ok {
+			return fmt.Errorf("unsupported EdDSA key type %T", private)
+		}
+		key.Private, key.Public = edPrivate, edPrivate.Public()
+	default:
+		return fmt.Errorf("unsupported JWT algorithm %q", key.Method.Alg())
+	}
+	return nil
+}
+
+func loadPublicKey(key *Key, path string) error {
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read JWT public key: %w", err)
+	}
+
+	switch m := key.Method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		key.Public, err = jwt.ParseRSAPublicKeyFromPEM(pemBytes)
+	case *jwt.SigningMethodECDSA:
+		public, parseErr := jwt.ParseECPublicKeyFromPEM(pemBytes)
+		if parseErr == nil && public.Curve.Params().BitSize != m.CurveBits {
+			parseErr = fmt.Errorf("EC key curve %s does not match %s", public.Curve.Params().Name, m.Alg())
+		}
+		key.Public, err = public, parseErr
+	case *jwt.SigningMethodEd25519:
+		key.Public, err = jwt.ParseEdPublicKeyFromPEM(pemBytes)
+	default:
+		return fmt.Errorf("unsupported JWT algorithm %q", key.Method.Alg())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse public key: %w", err)
+	}
+	return nil
+}
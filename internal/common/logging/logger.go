@@ -9,7 +9,9 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
-func InitLogger(levelStr, encoding string) (*zap.Logger, error) {
+// InitLogger builds the application logger. The returned level controls the
+// logger at runtime, so verbosity can be changed without a restart.
+func InitLogger(levelStr, encoding string) (*zap.Logger, zap.AtomicLevel, error) {
 	var level zapcore.Level
 	if err := level.UnmarshalText([]byte(levelStr)); err != nil {
 		fmt.Fprintf(os.Stderr, "Invalid log level '%s', defaulting to 'info'. Error: %v\n", levelStr, err)
@@ -27,7 +29,8 @@ func InitLogger(levelStr, encoding string) (*zap.Logger, error) {
 	default:
 		config = zap.NewDevelopmentConfig()
 	}
-	config.Level = zap.NewAtomicLevelAt(level)
+	atomicLevel := zap.NewAtomicLevelAt(level)
+	config.Level = atomicLevel
 	config.Encoding = encoding
 	config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 	config.DisableStacktrace = true
@@ -35,8 +38,8 @@ func InitLogger(levelStr, encoding string) (*zap.Logger, error) {
 	// AddCallerSkip(1) ensures the correct caller (e.g., handler function) is shown in logs, not this InitLogger function.
 	logger, err := config.Build(zap.AddCallerSkip(1))
 	if err != nil {
-		return nil, fmt.Errorf("failed to build zap logger: %w", err)
+		return nil, zap.AtomicLevel{}, fmt.Errorf("failed to build zap logger: %w", err)
 	}
 
-	return logger, nil
+	return logger, atomicLevel, nil
 }
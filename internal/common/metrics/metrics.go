@@ -0,0 +1,84 @@
+// Package metrics defines the Prometheus metrics of the service and the
+// handler that exposes them.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "goserve"
+
+// Registry holds every metric of the service. A dedicated registry keeps
+// metrics registered by dependencies on the default registry out of /metrics.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of HTTP requests handled, by route pattern, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	httpRequestDuration = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests, by route pattern, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	rateLimitRejections = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "rate_limit",
+		Name:      "rejections_total",
+		Help:      "Number of requests rejected by rate limiting, by policy and the limit that was hit.",
+	}, []string{"policy", "limit"})
+
+	authFailures = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "failures_total",
+		Help:      "Number of requests rejected as unauthenticated, by reason.",
+	}, []string{"reason"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves the metrics in Registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// ObserveHTTPRequest records a handled request. route is the pattern the
+// request matched, so that path parameters do not explode the label values.
+// Versioned routes are matched after the version prefix is stripped, so
+// "GET /v1/users/{id}" is recorded as "GET /users/{id}".
+func ObserveHTTPRequest(route, method string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(route, method, code).Inc()
+	httpRequestDuration.WithLabelValues(route, method, code).Observe(duration.Seconds())
+}
+
+// RateLimitRejected records a request rejected by policy. limit is "burst" for
+// the token bucket or the period of the exhausted quota.
+func RateLimitRejected(policy, limit string) {
+	rateLimitRejections.WithLabelValues(policy, limit).Inc()
+}
+
+// AuthFailed records a request rejected as unauthenticated.
+func AuthFailed(reason string) {
+	authFailures.WithLabelValues(reason).Inc()
+}
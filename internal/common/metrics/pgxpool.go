@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector reads the statistics of a pgx pool at scrape time.
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquiredConns           *prometheus.Desc
+	idleConns               *prometheus.Desc
+	constructingConns       *prometheus.Desc
+	totalConns              *prometheus.Desc
+	maxConns                *prometheus.Desc
+	acquireCount            *prometheus.Desc
+	acquireDuration         *prometheus.Desc
+	emptyAcquireCount       *prometheus.Desc
+	canceledAcquireCount    *prometheus.Desc
+	newConnsCount           *prometheus.Desc
+	maxLifetimeDestroyCount *prometheus.Desc
+	maxIdleDestroyCount     *prometheus.Desc
+}
+
+// RegisterPool exports the connection statistics of pool.
+func RegisterPool(pool *pgxpool.Pool) error {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+	return Registry.Register(&poolCollector{
+		pool:                    pool,
+		acquiredConns:           desc("acquired_connections", "Number of connections currently in use."),
+		idleConns:               desc("idle_connections", "Number of idle connections."),
+		constructingConns:       desc("constructing_connections", "Number of connections being established."),
+		totalConns:              desc("total_connections", "Total number of open connections."),
+		maxConns:                desc("max_connections", "Maximum size of the pool."),
+		acquireCount:            desc("acquires_total", "Number of successful connection acquires."),
+		acquireDuration:         desc("acquire_duration_seconds_total", "Total time spent acquiring connections."),
+		emptyAcquireCount:       desc("empty_acquires_total", "Number of acquires that had to wait because the pool was empty."),
+		canceledAcquireCount:    desc("canceled_acquires_total", "Number of acquires canceled by their context."),
+		newConnsCount:           desc("new_connections_total", "Number of connections opened."),
+		maxLifetimeDestroyCount: desc("max_lifetime_destroys_total", "Number of connections closed for exceeding their maximum lifetime."),
+		maxIdleDestroyCount:     desc("max_idle_destroys_total", "Number of connections closed for exceeding their maximum idle time."),
+	})
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	gauge := func(desc *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v)
+	}
+	counter := func(desc *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, v)
+	}
+
+	gauge(c.acquiredConns, float64(stat.AcquiredConns()))
+	gauge(c.idleConns, float64(stat.IdleConns()))
+	gauge(c.constructingConns, float64(stat.ConstructingConns()))
+	gauge(c.totalConns, float64(stat.TotalConns()))
+	gauge(c.maxConns, float64(stat.MaxConns()))
+	counter(c.acquireCount, float64(stat.AcquireCount()))
+	counter(c.acquireDuration, stat.AcquireDuration().Seconds())
+	counter(c.emptyAcquireCount, float64(stat.EmptyAcquireCount()))
+	counter(c.canceledAcquireCount, float64(stat.CanceledAcquireCount()))
+	counter(c.newConnsCount, float64(stat.NewConnsCount()))
+	counter(c.maxLifetimeDestroyCount, float64(stat.MaxLifetimeDestroyCount()))
+	counter(c.maxIdleDestroyCount, float64(stat.MaxIdleDestroyCount()))
+}
@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"container/list"
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Memory keeps one x/time/rate limiter per key in least recently used order.
+// Keys idle for longer than idleTimeout are dropped, and the least recently
+// used key is dropped once maxKeys is reached.
+type Memory struct {
+	mu          sync.Mutex
+	limit       rate.Limit
+	burst       int
+	maxKeys     int
+	idleTimeout time.Duration
+	keys        map[string]*list.Element
+	lru         *list.List
+}
+
+type memoryBucket struct {
+	key      string
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewMemory creates a Memory limiter allowing burst requests and then one
+// request every interval per key.
+func NewMemory(interval time.Duration, burst, maxKeys int, idleTimeout time.Duration) *Memory {
+	return &Memory{
+		limit:       rate.Every(interval),
+		burst:       burst,
+		maxKeys:     maxKeys,
+		idleTimeout: idleTimeout,
+		keys:        make(map[string]*list.Element),
+		lru:         list.New(),
+	}
+}
+
+func (m *Memory) Allow(_ context.Context, key string) (Result, error) {
+	now := time.Now()
+	limiter := m.get(key, now)
+
+	result := Result{Limit: m.burst}
+	reservation := limiter.ReserveN(now, 1)
+	switch {
+	case !reservation.OK():
+		result.RetryAfter = m.durationFor(1)
+	case reservation.DelayFrom(now) > 0:
+		result.RetryAfter = reservation.DelayFrom(now)
+		reservation.CancelAt(now)
+	default:
+		result.Allowed = true
+	}
+
+	tokens := limiter.TokensAt(now)
+	result.Remaining = max(int(math.Floor(tokens)), 0)
+	result.ResetAfter = m.durationFor(float64(m.burst) - tokens)
+	return result, nil
+}
+
+// durationFor returns how long the bucket takes to refill tokens.
+func (m *Memory) durationFor(tokens float64) time.Duration {
+	if tokens <= 0 || m.limit <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / float64(m.limit) * float64(time.Second))
+}
+
+func (m *Memory) get(key string, now time.Time) *rate.Limiter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.evictIdle(now)
+
+	if elem, ok := m.keys[key]; ok {
+		bucket := elem.Value.(*memoryBucket)
+		bucket.lastSeen = now
+		m.lru.MoveToFront(elem)
+		return bucket.limiter
+	}
+
+	if m.lru.Len() >= m.maxKeys {
+		m.remove(m.lru.Back())
+	}
+	bucket := &memoryBucket{key: key, limiter: rate.NewLimiter(m.limit, m.burst), lastSeen: now}
+	m.keys[key] = m.lru.PushFront(bucket)
+	return bucket.limiter
+}
+
+// evictIdle drops keys from the back of the list until it reaches one seen
+// within idleTimeout.
+func (m *Memory) evictIdle(now time.Time) {
+	for elem := m.lru.Back(); elem != nil; elem = m.lru.Back() {
+		if now.Sub(elem.Value.(*memoryBucket).lastSeen) < m.idleTimeout {
+			return
+		}
+		m.remove(elem)
+	}
+}
+
+func (m *Memory) remove(elem *list.Element) {
+	m.lru.Remove(elem)
+	delete(m.keys, elem.Value.(*memoryBucket).key)
+}
@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Period is a calendar window that quotas are counted over, in UTC.
+type Period string
+
+const (
+	PeriodDay   Period = "day"
+	PeriodMonth Period = "month"
+)
+
+// window returns an identifier for the window containing now and the time
+// the window ends.
+func (p Period) window(now time.Time) (string, time.Time) {
+	now = now.UTC()
+	if p == PeriodMonth {
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start.Format("2006-01"), start.AddDate(0, 1, 0)
+	}
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return start.Format("2006-01-02"), start.AddDate(0, 0, 1)
+}
+
+// QuotaCounter counts requests per key in the current window of a period.
+type QuotaCounter interface {
+	// Increment adds one request and returns the count in the current window,
+	// including this request, and the time the window resets.
+	Increment(ctx context.Context, key string, period Period) (int64, time.Time, error)
+}
+
+// MemoryQuota counts requests in process memory. Counts are per replica and
+// lost on restart, so it is only suitable for a single instance or as a
+// fallback.
+type MemoryQuota struct {
+	mu        sync.Mutex
+	counts    map[string]*memoryCount
+	lastSweep time.Time
+}
+
+type memoryCount struct {
+	count   int64
+	resetAt time.Time
+}
+
+// NewMemoryQuota creates an empty MemoryQuota.
+func NewMemoryQuota() *MemoryQuota {
+	return &MemoryQuota{counts: make(map[string]*memoryCount)}
+}
+
+func (m *MemoryQuota) Increment(_ context.Context, key string, period Period) (int64, time.Time, error) {
+	now := time.Now()
+	window, resetAt := period.window(now)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Windows only move forward, so expired counts are dropped once a minute.
+	if now.Sub(m.lastSweep) > time.Minute {
+		for k, c := range m.counts {
+			if !now.Before(c.resetAt) {
+				delete(m.counts, k)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	k := key + ":" + string(period) + ":" + window
+	c, ok := m.counts[k]
+	if !ok {
+		c = &memoryCount{resetAt: resetAt}
+		m.counts[k] = c
+	}
+	c.count++
+	return c.count, c.resetAt, nil
+}
+
+// incrementScript increments KEYS[1] and sets it to expire at ARGV[1], a
+// Unix time, when the key is created.
+var incrementScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+  redis.call('EXPIREAT', KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// RedisQuota counts requests in Redis so every replica shares the count.
+// Each window is its own key and expires an hour after the window ends.
+type RedisQuota struct {
+	client    redis.Scripter
+	keyPrefix string
+}
+
+// NewRedisQuota creates a RedisQuota storing counts under keyPrefix.
+func NewRedisQuota(client redis.Scripter, keyPrefix string) *RedisQuota {
+	return &RedisQuota{client: client, keyPrefix: keyPrefix}
+}
+
+func (r *RedisQuota) Increment(ctx context.Context, key string, period Period) (int64, time.Time, error) {
+	window, resetAt := period.window(time.Now())
+	redisKey := r.keyPrefix + key + ":" + string(period) + ":" + window
+
+	count, err := incrementScript.Run(ctx, r.client, []string{redisKey}, resetAt.Add(time.Hour).Unix()).Int64()
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("ratelimit: failed to increment quota: %w", err)
+	}
+	return count, resetAt, nil
+}
+
+// QuotaFallback uses primary and switches to fallback while breaker is open.
+type QuotaFallback struct {
+	primary  QuotaCounter
+	fallback QuotaCounter
+	breaker  *Breaker
+}
+
+// NewQuotaFallback creates a QuotaCounter that degrades from primary to fallback.
+func NewQuotaFallback(primary, fallback QuotaCounter, breaker *Breaker) *QuotaFallback {
+	return &QuotaFallback{
+		primary:  primary,
+		fallback: fallback,
+		breaker:  breaker,
+	}
+}
+
+func (f *QuotaFallback) Increment(ctx context.Context, key string, period Period) (int64, time.Time, error) {
+	if f.breaker.closed() {
+		count, resetAt, err := f.primary.Increment(ctx, key, period)
+		if err == nil {
+			return count, resetAt, nil
+		}
+		f.breaker.trip(err)
+	}
+	return f.fallback.Increment(ctx, key, period)
+}
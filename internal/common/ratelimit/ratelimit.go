@@ -0,0 +1,83 @@
+// Package ratelimit implements per-key token bucket rate limiters kept either
+// in process memory or in Redis so that every replica shares one quota.
+package ratelimit
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Result is the outcome of taking one token for a key.
+type Result struct {
+	Allowed bool
+	// Limit is the bucket size, the most requests that can be made at once.
+	Limit int
+	// Remaining is the number of whole tokens left after this request.
+	Remaining int
+	// RetryAfter is how long until the next token is available; zero when
+	// Allowed is true.
+	RetryAfter time.Duration
+	// ResetAfter is how long until the bucket is full again.
+	ResetAfter time.Duration
+}
+
+// RateLimiter takes a token from the bucket for key. An error means the
+// limiter could not decide, not that the request is limited.
+type RateLimiter interface {
+	Allow(ctx context.Context, key string) (Result, error)
+}
+
+// Breaker tracks whether a shared backend such as Redis is failing. After
+// an error the backend is skipped for retryInterval so an unavailable Redis
+// does not add a timeout to every request. One Breaker can be shared by all
+// limiters and counters that use the same backend.
+type Breaker struct {
+	retryInterval time.Duration
+	logger        *zap.Logger
+	// skipUntil holds the UnixNano time until which the backend is skipped.
+	skipUntil atomic.Int64
+}
+
+// NewBreaker creates a Breaker that skips a failing backend for retryInterval.
+func NewBreaker(retryInterval time.Duration, logger *zap.Logger) *Breaker {
+	return &Breaker{retryInterval: retryInterval, logger: logger}
+}
+
+func (b *Breaker) closed() bool {
+	return time.Now().UnixNano() >= b.skipUntil.Load()
+}
+
+func (b *Breaker) trip(err error) {
+	b.skipUntil.Store(time.Now().Add(b.retryInterval).UnixNano())
+	b.logger.Warn("Primary rate limit backend failed, falling back to in-memory limits", zap.Error(err), zap.Duration("retry_interval", b.retryInterval))
+}
+
+// Fallback uses primary and switches to fallback while breaker is open.
+type Fallback struct {
+	primary  RateLimiter
+	fallback RateLimiter
+	breaker  *Breaker
+}
+
+// NewFallback creates a RateLimiter that degrades from primary to fallback.
+func NewFallback(primary, fallback RateLimiter, breaker *Breaker) *Fallback {
+	return &Fallback{
+		primary:  primary,
+		fallback: fallback,
+		breaker:  breaker,
+	}
+}
+
+func (f *Fallback) Allow(ctx context.Context, key string) (Result, error) {
+	if f.breaker.closed() {
+		result, err := f.primary.Allow(ctx, key)
+		if err == nil {
+			return result, nil
+		}
+		f.breaker.trip(err)
+	}
+	return f.fallback.Allow(ctx, key)
+}
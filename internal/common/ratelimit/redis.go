@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript refills and takes from the bucket in KEYS[1] atomically.
+// It uses the Redis server clock so replicas with skewed clocks agree.
+// ARGV[1] is microseconds per token and ARGV[2] the bucket size.
+// It returns {allowed, remaining, retry_after_us, reset_after_us}.
+var tokenBucketScript = redis.NewScript(`
+local interval = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+  tokens = burst
+  ts = now
+end
+tokens = math.min(burst, tokens + math.max(0, now - ts) / interval)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  retry = math.ceil((1 - tokens) * interval)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * interval / 1000) + 1000)
+return {allowed, math.floor(tokens), retry, math.ceil((burst - tokens) * interval)}
+`)
+
+// Redis is a token bucket limiter whose state lives in Redis, so every
+// replica shares one bucket per key. Buckets expire once they would be full.
+type Redis struct {
+	client    redis.Scripter
+	keyPrefix string
+	interval  time.Duration
+	burst     int
+}
+
+// NewRedis creates a Redis limiter allowing burst requests and then one
+// request every interval per key. Keys are stored under keyPrefix.
+func NewRedis(client redis.Scripter, keyPrefix string, interval time.Duration, burst int) *Redis {
+	return &Redis{
+		client:    client,
+		keyPrefix: keyPrefix,
+		interval:  interval,
+		burst:     burst,
+	}
+}
+
+func (r *Redis) Allow(ctx context.Context, key string) (Result, error) {
+	values, err := tokenBucketScript.Run(ctx, r.client, []string{r.keyPrefix + key}, r.interval.Microseconds(), r.burst).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit: failed to run token bucket script: %w", err)
+	}
+	if len(values) != 4 {
+		return Result{}, fmt.Errorf("ratelimit: unexpected token bucket reply %v", values)
+	}
+
+	return Result{
+		Allowed:    values[0] == 1,
+		Limit:      r.burst,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Microsecond,
+		ResetAfter: time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
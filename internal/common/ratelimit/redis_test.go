@@ -0,0 +1,194 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	// The token bucket reads the Redis clock, so tests move it explicitly.
+	mr.SetTime(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := redis.NewClient(&redis.Options{
+		Addr:       mr.Addr(),
+		MaxRetries: -1,
+	})
+	t.Cleanup(func() { client.Close() })
+	return mr, client
+}
+
+func allow(t *testing.T, limiter RateLimiter, key string) Result {
+	t.Helper()
+	result, err := limiter.Allow(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Allow(%q) failed: %v", key, err)
+	}
+	return result
+}
+
+func TestRedisBurstExhaustion(t *testing.T) {
+	_, client := newTestRedis(t)
+	limiter := NewRedis(client, "test:", time.Second, 3)
+
+	for i := range 3 {
+		result := allow(t, limiter, "alice")
+		if !result.Allowed {
+			t.Fatalf("request %d rejected within burst", i+1)
+		}
+		if want := 2 - i; result.Remaining != want {
+			t.Errorf("request %d: remaining = %d, want %d", i+1, result.Remaining, want)
+		}
+	}
+
+	result := allow(t, limiter, "alice")
+	if result.Allowed {
+		t.Fatal("request beyond burst allowed")
+	}
+	if result.RetryAfter != time.Second {
+		t.Errorf("retry after = %s, want 1s", result.RetryAfter)
+	}
+	if result.Limit != 3 {
+		t.Errorf("limit = %d, want 3", result.Limit)
+	}
+}
+
+func TestRedisRefill(t *testing.T) {
+	mr, client := newTestRedis(t)
+	limiter := NewRedis(client, "test:", time.Second, 2)
+
+	allow(t, limiter, "alice")
+	allow(t, limiter, "alice")
+	if allow(t, limiter, "alice").Allowed {
+		t.Fatal("request beyond burst allowed")
+	}
+
+	mr.SetTime(time.Date(2026, 1, 1, 0, 0, 1, 0, time.UTC))
+	if !allow(t, limiter, "alice").Allowed {
+		t.Fatal("request rejected after one token refilled")
+	}
+	if allow(t, limiter, "alice").Allowed {
+		t.Fatal("second request allowed after only one token refilled")
+	}
+}
+
+func TestRedisLimitsPerKey(t *testing.T) {
+	_, client := newTestRedis(t)
+	limiter := NewRedis(client, "test:", time.Minute, 1)
+
+	allow(t, limiter, "alice")
+	if allow(t, limiter, "alice").Allowed {
+		t.Fatal("alice allowed beyond burst")
+	}
+	if !allow(t, limiter, "bob").Allowed {
+		t.Fatal("bob limited by alice's bucket")
+	}
+}
+
+func TestFallbackToMemoryAndRecovery(t *testing.T) {
+	mr, client := newTestRedis(t)
+	breaker := NewBreaker(50*time.Millisecond, zap.NewNop())
+	limiter := NewFallback(
+		NewRedis(client, "test:", time.Minute, 1),
+		NewMemory(time.Minute, 1, 100, time.Minute),
+		breaker,
+	)
+
+	allow(t, limiter, "alice")
+	if allow(t, limiter, "alice").Allowed {
+		t.Fatal("redis bucket not shared between calls")
+	}
+
+	mr.Close()
+	if !allow(t, limiter, "alice").Allowed {
+		t.Fatal("request not served from the memory fallback while redis is down")
+	}
+	if breaker.closed() {
+		t.Fatal("breaker not tripped by the redis failure")
+	}
+	if allow(t, limiter, "alice").Allowed {
+		t.Fatal("memory fallback did not limit")
+	}
+
+	if err := mr.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if !breaker.closed() {
+		t.Fatal("breaker still open after the retry interval")
+	}
+	// Redis refills on its own clock while the memory bucket stays empty, so
+	// an allowed request shows redis is serving again.
+	mr.SetTime(time.Date(2026, 1, 1, 0, 1, 0, 0, time.UTC))
+	if !allow(t, limiter, "alice").Allowed {
+		t.Fatal("request not served by redis after recovery")
+	}
+	if allow(t, limiter, "alice").Allowed {
+		t.Fatal("redis did not limit after recovery")
+	}
+	if !breaker.closed() {
+		t.Fatal("breaker tripped after recovery")
+	}
+}
+
+func TestRedisQuota(t *testing.T) {
+	mr, client := newTestRedis(t)
+	quota := NewRedisQuota(client, "quota:")
+	ctx := context.Background()
+
+	for want := int64(1); want <= 3; want++ {
+		count, resetAt, err := quota.Increment(ctx, "alice", PeriodDay)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != want {
+			t.Errorf("count = %d, want %d", count, want)
+		}
+		if !resetAt.After(time.Now()) {
+			t.Errorf("reset at %s is not in the future", resetAt)
+		}
+	}
+
+	count, _, err := quota.Increment(ctx, "bob", PeriodDay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("bob count = %d, want 1", count)
+	}
+
+	keys := mr.Keys()
+	if len(keys) != 2 {
+		t.Fatalf("keys = %v, want one per client", keys)
+	}
+	for _, key := range keys {
+		if ttl := mr.TTL(key); ttl <= 0 {
+			t.Errorf("key %s has no expiry", key)
+		}
+	}
+}
+
+func TestQuotaFallbackToMemory(t *testing.T) {
+	mr, client := newTestRedis(t)
+	breaker := NewBreaker(time.Minute, zap.NewNop())
+	quota := NewQuotaFallback(NewRedisQuota(client, "quota:"), NewMemoryQuota(), breaker)
+	ctx := context.Background()
+
+	if count, _, err := quota.Increment(ctx, "alice", PeriodMonth); err != nil || count != 1 {
+		t.Fatalf("Increment = %d, %v; want 1, nil", count, err)
+	}
+
+	mr.Close()
+	count, _, err := quota.Increment(ctx, "alice", PeriodMonth)
+	if err != nil {
+		t.Fatalf("Increment failed while redis is down: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("memory count = %d, want 1", count)
+	}
+}
@@ -17,6 +17,7 @@ func ConnectDB(dbURL string, maxConn int) (*pgxpool.Pool, error) {
 	}
 
 	config.MaxConns = int32(maxConn)
+	config.ConnConfig.Tracer = newQueryTracer()
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
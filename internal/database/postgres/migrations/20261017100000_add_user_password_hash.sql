@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Existing users get an empty hash, which never matches, so they cannot log in
+-- until an admin sets their password with PUT /v1/users/{id}/password.
+ALTER TABLE users ADD COLUMN password_hash TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN IF EXISTS password_hash;
+-- +goose StatementEnd
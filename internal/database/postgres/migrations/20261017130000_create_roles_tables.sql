@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE roles (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+INSERT INTO roles (name, description) VALUES
+    ('admin', 'Full access, including user and role management'),
+    ('editor', 'Can create and edit content'),
+    ('reader', 'Can read content');
+
+CREATE TABLE user_roles (
+    user_id UUID NOT NULL,
+    role TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, role),
+    CONSTRAINT fk_user_role_user
+        FOREIGN KEY(user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_user_role_role
+        FOREIGN KEY(role)
+        REFERENCES roles(name)
+        ON DELETE CASCADE
+);
+
+-- Existing users keep the access they had before roles existed
+INSERT INTO user_roles (user_id, role) SELECT id, 'reader' FROM users;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS roles;
+-- +goose StatementEnd
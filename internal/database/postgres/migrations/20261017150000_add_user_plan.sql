@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The plan selects the rate limits and usage quotas applied to the user and their API keys.
+ALTER TABLE users ADD COLUMN plan TEXT NOT NULL DEFAULT 'free'
+    CONSTRAINT chk_user_plan CHECK (plan IN ('free', 'pro', 'internal'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN IF EXISTS plan;
+-- +goose StatementEnd
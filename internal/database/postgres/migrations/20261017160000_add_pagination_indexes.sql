@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- List endpoints page through rows by (created_at, id), seeking past the cursor
+-- with a row comparison. The planner can start an index scan at the cursor,
+-- forwards or backwards, and stop after the page; other filters are checked on
+-- the rows read. The author index serves lists filtered by author.
+CREATE INDEX IF NOT EXISTS idx_users_created_at_id ON users (created_at, id);
+CREATE INDEX IF NOT EXISTS idx_articles_created_at_id ON articles (created_at, id);
+CREATE INDEX IF NOT EXISTS idx_articles_author_id_created_at_id ON articles (author_id, created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_articles_author_id_created_at_id;
+DROP INDEX IF EXISTS idx_articles_created_at_id;
+DROP INDEX IF EXISTS idx_users_created_at_id;
+-- +goose StatementEnd
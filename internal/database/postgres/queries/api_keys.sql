@@ -0,0 +1,21 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at;
+
+-- name: GetAPIKeyByID :one
+SELECT id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at FROM api_keys WHERE id = $1 LIMIT 1;
+
+-- name: GetAPIKeyByHash :one
+SELECT id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at FROM api_keys WHERE key_hash = $1 LIMIT 1;
+
+-- name: ListAPIKeysByUserID :many
+SELECT id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC;
+
+-- name: RevokeAPIKey :exec
+UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL;
+
+-- name: TouchAPIKeyLastUsed :exec
+-- Throttled to one write per key per minute so hot keys do not write on every request.
+UPDATE api_keys SET last_used_at = NOW()
+WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute');
@@ -4,14 +4,38 @@ INSERT INTO articles (title, content, author_id) VALUES ($1, $2, $3) RETURNING i
 -- name: GetArticleByID :one
 SELECT id, title, content, author_id, created_at, updated_at FROM articles WHERE id = $1 LIMIT 1;
 
--- name: ListArticles :many
-SELECT id, title, content, author_id, created_at, updated_at FROM articles ORDER BY created_at DESC;
+-- name: ListArticlesAscending :many
+-- Keyset pagination in ascending (created_at, id) order: a page starts after
+-- the cursor row, so the first page passes -infinity. NULL filters are ignored.
+SELECT id, title, content, author_id, created_at, updated_at FROM articles
+WHERE (created_at, id) > (sqlc.narg('cursor_created_at')::timestamptz, sqlc.narg('cursor_id')::uuid)
+  AND (sqlc.narg('author_id')::uuid IS NULL OR author_id = sqlc.narg('author_id')::uuid)
+  AND (sqlc.narg('created_after')::timestamptz IS NULL OR created_at > sqlc.narg('created_after')::timestamptz)
+  AND (sqlc.narg('created_before')::timestamptz IS NULL OR created_at < sqlc.narg('created_before')::timestamptz)
+ORDER BY created_at, id
+LIMIT sqlc.arg('page_limit');
 
--- name: UpdateArticle :one
-UPDATE articles SET title = $2, content = $3, updated_at = NOW() WHERE id = $1 RETURNING id, title, content, author_id, created_at, updated_at;
+-- name: ListArticlesDescending :many
+-- Keyset pagination in descending (created_at, id) order: a page starts after
+-- the cursor row, so the first page passes infinity. NULL filters are ignored.
+SELECT id, title, content, author_id, created_at, updated_at FROM articles
+WHERE (created_at, id) < (sqlc.narg('cursor_created_at')::timestamptz, sqlc.narg('cursor_id')::uuid)
+  AND (sqlc.narg('author_id')::uuid IS NULL OR author_id = sqlc.narg('author_id')::uuid)
+  AND (sqlc.narg('created_after')::timestamptz IS NULL OR created_at > sqlc.narg('created_after')::timestamptz)
+  AND (sqlc.narg('created_before')::timestamptz IS NULL OR created_at < sqlc.narg('created_before')::timestamptz)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_limit');
 
--- name: DeleteArticle :exec
-DELETE FROM articles WHERE id = $1;
+-- name: UpdateArticle :one
+-- Only the author can update, checked in the same statement as the write.
+-- NULL arguments keep the current column value so callers can apply partial updates.
+UPDATE articles SET
+    title = COALESCE(sqlc.narg('title'), title),
+    content = COALESCE(sqlc.narg('content'), content),
+    updated_at = NOW()
+WHERE id = sqlc.arg('id') AND author_id = sqlc.arg('author_id')
+RETURNING id, title, content, author_id, created_at, updated_at;
 
--- name: ListArticlesByAuthorID :many
-SELECT id, title, content, author_id, created_at, updated_at FROM articles WHERE author_id = $1 ORDER BY created_at DESC;
+-- name: DeleteArticle :execrows
+-- Only the author can delete, checked in the same statement as the write.
+DELETE FROM articles WHERE id = $1 AND author_id = $2;
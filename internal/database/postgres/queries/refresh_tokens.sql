@@ -0,0 +1,14 @@
+-- name: CreateRefreshToken :one
+INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id, user_id, family_id, token_hash, expires_at, rotated_at, revoked_at, created_at;
+
+-- name: GetRefreshTokenByHash :one
+SELECT id, user_id, family_id, token_hash, expires_at, rotated_at, revoked_at, created_at FROM refresh_tokens WHERE token_hash = $1 LIMIT 1;
+
+-- name: MarkRefreshTokenRotated :execrows
+-- Only succeeds for a live token, so two concurrent rotations cannot both win.
+UPDATE refresh_tokens SET rotated_at = NOW() WHERE id = $1 AND rotated_at IS NULL AND revoked_at IS NULL;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL;
@@ -0,0 +1,8 @@
+-- name: ListUserRoles :many
+SELECT role FROM user_roles WHERE user_id = $1 ORDER BY role;
+
+-- name: AssignUserRole :exec
+INSERT INTO user_roles (user_id, role) VALUES ($1, $2) ON CONFLICT (user_id, role) DO NOTHING;
+
+-- name: RemoveUserRole :exec
+DELETE FROM user_roles WHERE user_id = $1 AND role = $2;
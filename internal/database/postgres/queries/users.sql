@@ -1,19 +1,59 @@
 -- name: CreateUser :one
-INSERT INTO users (username, email)
-VALUES ($1,$2)
-RETURNING id, username, email, created_at, updated_at;
+INSERT INTO users (username, email, password_hash)
+VALUES ($1,$2,$3)
+RETURNING id, username, email, created_at, updated_at, password_hash, plan;
 
 -- name: GetUserByID :one
-SELECT id, username, email, created_at, updated_at FROM users WHERE id = $1 LIMIT 1;
+SELECT id, username, email, created_at, updated_at, password_hash, plan FROM users WHERE id = $1 LIMIT 1;
 
 -- name: GetUserByEmail :one
-SELECT id, username, email, created_at, updated_at FROM users WHERE email = $1 LIMIT 1;
+SELECT id, username, email, created_at, updated_at, password_hash, plan FROM users WHERE email = $1 LIMIT 1;
 
--- name: ListUsers :many
-SELECT id, username, email, created_at, updated_at FROM users ORDER BY created_at DESC;
+-- name: ListUsersAscending :many
+-- Keyset pagination in ascending (created_at, id) order: a page starts after
+-- the cursor row, so the first page passes -infinity. NULL filters are ignored.
+SELECT id, username, email, created_at, updated_at, password_hash, plan FROM users
+WHERE (created_at, id) > (sqlc.narg('cursor_created_at')::timestamptz, sqlc.narg('cursor_id')::uuid)
+  AND (sqlc.narg('username_prefix')::text IS NULL OR starts_with(username, sqlc.narg('username_prefix')::text))
+  AND (sqlc.narg('email_domain')::text IS NULL OR lower(split_part(email, '@', 2)) = lower(sqlc.narg('email_domain')::text))
+  AND (sqlc.narg('created_after')::timestamptz IS NULL OR created_at > sqlc.narg('created_after')::timestamptz)
+  AND (sqlc.narg('created_before')::timestamptz IS NULL OR created_at < sqlc.narg('created_before')::timestamptz)
+ORDER BY created_at, id
+LIMIT sqlc.arg('page_limit');
+
+-- name: ListUsersDescending :many
+-- Keyset pagination in descending (created_at, id) order: a page starts after
+-- the cursor row, so the first page passes infinity. NULL filters are ignored.
+SELECT id, username, email, created_at, updated_at, password_hash, plan FROM users
+WHERE (created_at, id) < (sqlc.narg('cursor_created_at')::timestamptz, sqlc.narg('cursor_id')::uuid)
+  AND (sqlc.narg('username_prefix')::text IS NULL OR starts_with(username, sqlc.narg('username_prefix')::text))
+  AND (sqlc.narg('email_domain')::text IS NULL OR lower(split_part(email, '@', 2)) = lower(sqlc.narg('email_domain')::text))
+  AND (sqlc.narg('created_after')::timestamptz IS NULL OR created_at > sqlc.narg('created_after')::timestamptz)
+  AND (sqlc.narg('created_before')::timestamptz IS NULL OR created_at < sqlc.narg('created_before')::timestamptz)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_limit');
 
 -- name: UpdateUser :one
-UPDATE users SET username = $2, email = $3, updated_at = NOW() WHERE id = $1 RETURNING id, username, email, created_at, updated_at;
+-- NULL arguments keep the current column value so callers can apply partial updates.
+UPDATE users SET
+    username = COALESCE(sqlc.narg('username'), username),
+    email = COALESCE(sqlc.narg('email'), email),
+    updated_at = NOW()
+WHERE id = sqlc.arg('id')
+RETURNING id, username, email, created_at, updated_at, password_hash, plan;
+
+-- name: UpdateUserPassword :execrows
+UPDATE users SET
+    password_hash = $2,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: UpdateUserPlan :one
+UPDATE users SET
+    plan = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, username, email, created_at, updated_at, password_hash, plan;
 
 -- name: DeleteUser :exec
 DELETE FROM users where id = $1;
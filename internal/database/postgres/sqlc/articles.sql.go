@@ -9,6 +9,7 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createArticle = `-- name: CreateArticle :one
@@ -35,13 +36,22 @@ func (q *Queries) CreateArticle(ctx context.Context, arg CreateArticleParams) (A
 	return i, err
 }
 
-const deleteArticle = `-- name: DeleteArticle :exec
-DELETE FROM articles WHERE id = $1
+const deleteArticle = `-- name: DeleteArticle :execrows
+DELETE FROM articles WHERE id = $1 AND author_id = $2
 `
 
-func (q *Queries) DeleteArticle(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.Exec(ctx, deleteArticle, id)
-	return err
+type DeleteArticleParams struct {
+	ID       uuid.UUID `db:"id" json:"id"`
+	AuthorID uuid.UUID `db:"author_id" json:"author_id"`
+}
+
+// Only the author can delete, checked in the same statement as the write.
+func (q *Queries) DeleteArticle(ctx context.Context, arg DeleteArticleParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteArticle, arg.ID, arg.AuthorID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const getArticleByID = `-- name: GetArticleByID :one
@@ -62,12 +72,36 @@ func (q *Queries) GetArticleByID(ctx context.Context, id uuid.UUID) (Article, er
 	return i, err
 }
 
-const listArticles = `-- name: ListArticles :many
-SELECT id, title, content, author_id, created_at, updated_at FROM articles ORDER BY created_at DESC
+const listArticlesAscending = `-- name: ListArticlesAscending :many
+SELECT id, title, content, author_id, created_at, updated_at FROM articles
+WHERE (created_at, id) > ($1::timestamptz, $2::uuid)
+  AND ($3::uuid IS NULL OR author_id = $3::uuid)
+  AND ($4::timestamptz IS NULL OR created_at > $4::timestamptz)
+  AND ($5::timestamptz IS NULL OR created_at < $5::timestamptz)
+ORDER BY created_at, id
+LIMIT $6
 `
 
-func (q *Queries) ListArticles(ctx context.Context) ([]Article, error) {
-	rows, err := q.db.Query(ctx, listArticles)
+type ListArticlesAscendingParams struct {
+	CursorCreatedAt pgtype.Timestamptz `db:"cursor_created_at" json:"cursor_created_at"`
+	CursorID        pgtype.UUID        `db:"cursor_id" json:"cursor_id"`
+	AuthorID        pgtype.UUID        `db:"author_id" json:"author_id"`
+	CreatedAfter    pgtype.Timestamptz `db:"created_after" json:"created_after"`
+	CreatedBefore   pgtype.Timestamptz `db:"created_before" json:"created_before"`
+	PageLimit       int32              `db:"page_limit" json:"page_limit"`
+}
+
+// Keyset pagination in ascending (created_at, id) order: a page starts after
+// the cursor row, so the first page passes -infinity. NULL filters are ignored.
+func (q *Queries) ListArticlesAscending(ctx context.Context, arg ListArticlesAscendingParams) ([]Article, error) {
+	rows, err := q.db.Query(ctx, listArticlesAscending,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.AuthorID,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -93,12 +127,36 @@ func (q *Queries) ListArticles(ctx context.Context) ([]Article, error) {
 	return items, nil
 }
 
-const listArticlesByAuthorID = `-- name: ListArticlesByAuthorID :many
-SELECT id, title, content, author_id, created_at, updated_at FROM articles WHERE author_id = $1 ORDER BY created_at DESC
+const listArticlesDescending = `-- name: ListArticlesDescending :many
+SELECT id, title, content, author_id, created_at, updated_at FROM articles
+WHERE (created_at, id) < ($1::timestamptz, $2::uuid)
+  AND ($3::uuid IS NULL OR author_id = $3::uuid)
+  AND ($4::timestamptz IS NULL OR created_at > $4::timestamptz)
+  AND ($5::timestamptz IS NULL OR created_at < $5::timestamptz)
+ORDER BY created_at DESC, id DESC
+LIMIT $6
 `
 
-func (q *Queries) ListArticlesByAuthorID(ctx context.Context, authorID uuid.UUID) ([]Article, error) {
-	rows, err := q.db.Query(ctx, listArticlesByAuthorID, authorID)
+type ListArticlesDescendingParams struct {
+	CursorCreatedAt pgtype.Timestamptz `db:"cursor_created_at" json:"cursor_created_at"`
+	CursorID        pgtype.UUID        `db:"cursor_id" json:"cursor_id"`
+	AuthorID        pgtype.UUID        `db:"author_id" json:"author_id"`
+	CreatedAfter    pgtype.Timestamptz `db:"created_after" json:"created_after"`
+	CreatedBefore   pgtype.Timestamptz `db:"created_before" json:"created_before"`
+	PageLimit       int32              `db:"page_limit" json:"page_limit"`
+}
+
+// Keyset pagination in descending (created_at, id) order: a page starts after
+// the cursor row, so the first page passes infinity. NULL filters are ignored.
+func (q *Queries) ListArticlesDescending(ctx context.Context, arg ListArticlesDescendingParams) ([]Article, error) {
+	rows, err := q.db.Query(ctx, listArticlesDescending,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.AuthorID,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -125,17 +183,30 @@ func (q *Queries) ListArticlesByAuthorID(ctx context.Context, authorID uuid.UUID
 }
 
 const updateArticle = `-- name: UpdateArticle :one
-UPDATE articles SET title = $2, content = $3, updated_at = NOW() WHERE id = $1 RETURNING id, title, content, author_id, created_at, updated_at
+UPDATE articles SET
+    title = COALESCE($1, title),
+    content = COALESCE($2, content),
+    updated_at = NOW()
+WHERE id = $3 AND author_id = $4
+RETURNING id, title, content, author_id, created_at, updated_at
 `
 
 type UpdateArticleParams struct {
-	ID      uuid.UUID `db:"id" json:"id"`
-	Title   string    `db:"title" json:"title"`
-	Content string    `db:"content" json:"content"`
+	Title    pgtype.Text `db:"title" json:"title"`
+	Content  pgtype.Text `db:"content" json:"content"`
+	ID       uuid.UUID   `db:"id" json:"id"`
+	AuthorID uuid.UUID   `db:"author_id" json:"author_id"`
 }
 
+// Only the author can update, checked in the same statement as the write.
+// NULL arguments keep the current column value so callers can apply partial updates.
 func (q *Queries) UpdateArticle(ctx context.Context, arg UpdateArticleParams) (Article, error) {
-	row := q.db.QueryRow(ctx, updateArticle, arg.ID, arg.Title, arg.Content)
+	row := q.db.QueryRow(ctx, updateArticle,
+		arg.Title,
+		arg.Content,
+		arg.ID,
+		arg.AuthorID,
+	)
 	var i Article
 	err := row.Scan(
 		&i.ID,
@@ -5,10 +5,25 @@
 package db
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type APIKey struct {
+	ID         uuid.UUID          `db:"id" json:"id"`
+	UserID     uuid.UUID          `db:"user_id" json:"user_id"`
+	Name       string             `db:"name" json:"name"`
+	Prefix     string             `db:"prefix" json:"prefix"`
+	KeyHash    string             `db:"key_hash" json:"-"`
+	Scopes     []string           `db:"scopes" json:"scopes"`
+	ExpiresAt  pgtype.Timestamptz `db:"expires_at" json:"expires_at"`
+	LastUsedAt pgtype.Timestamptz `db:"last_used_at" json:"last_used_at"`
+	RevokedAt  pgtype.Timestamptz `db:"revoked_at" json:"revoked_at"`
+	CreatedAt  pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
 type Article struct {
 	ID        uuid.UUID          `db:"id" json:"id"`
 	Title     string             `db:"title" json:"title"`
@@ -18,10 +33,41 @@ type Article struct {
 	UpdatedAt pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
 }
 
-type User struct {
+type RefreshToken struct {
 	ID        uuid.UUID          `db:"id" json:"id"`
-	Username  string             `db:"username" json:"username"`
-	Email     string             `db:"email" json:"email"`
+	UserID    uuid.UUID          `db:"user_id" json:"user_id"`
+	FamilyID  uuid.UUID          `db:"family_id" json:"family_id"`
+	TokenHash string             `db:"token_hash" json:"-"`
+	ExpiresAt time.Time          `db:"expires_at" json:"expires_at"`
+	RotatedAt pgtype.Timestamptz `db:"rotated_at" json:"rotated_at"`
+	RevokedAt pgtype.Timestamptz `db:"revoked_at" json:"revoked_at"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type RevokedToken struct {
+	Jti       string             `db:"jti" json:"jti"`
+	ExpiresAt time.Time          `db:"expires_at" json:"expires_at"`
+	RevokedAt pgtype.Timestamptz `db:"revoked_at" json:"revoked_at"`
+}
+
+type Role struct {
+	Name        string             `db:"name" json:"name"`
+	Description string             `db:"description" json:"description"`
+	CreatedAt   pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type User struct {
+	ID           uuid.UUID          `db:"id" json:"id"`
+	Username     string             `db:"username" json:"username"`
+	Email        string             `db:"email" json:"email"`
+	CreatedAt    pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt    pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+	PasswordHash string             `db:"password_hash" json:"-"`
+	Plan         string             `db:"plan" json:"plan"`
+}
+
+type UserRole struct {
+	UserID    uuid.UUID          `db:"user_id" json:"user_id"`
+	Role      string             `db:"role" json:"role"`
 	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
-	UpdatedAt pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
 }
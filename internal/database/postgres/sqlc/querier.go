@@ -11,18 +11,51 @@ import (
 )
 
 type Querier interface {
+	AssignUserRole(ctx context.Context, arg AssignUserRoleParams) error
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (APIKey, error)
 	CreateArticle(ctx context.Context, arg CreateArticleParams) (Article, error)
+	CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
-	DeleteArticle(ctx context.Context, id uuid.UUID) error
+	// Only the author can delete, checked in the same statement as the write.
+	DeleteArticle(ctx context.Context, arg DeleteArticleParams) (int64, error)
+	DeleteExpiredRevokedTokens(ctx context.Context) (int64, error)
 	DeleteUser(ctx context.Context, id uuid.UUID) error
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (APIKey, error)
+	GetAPIKeyByID(ctx context.Context, id uuid.UUID) (APIKey, error)
 	GetArticleByID(ctx context.Context, id uuid.UUID) (Article, error)
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (RefreshToken, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByID(ctx context.Context, id uuid.UUID) (User, error)
-	ListArticles(ctx context.Context) ([]Article, error)
-	ListArticlesByAuthorID(ctx context.Context, authorID uuid.UUID) ([]Article, error)
-	ListUsers(ctx context.Context) ([]User, error)
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	ListAPIKeysByUserID(ctx context.Context, userID uuid.UUID) ([]APIKey, error)
+	// Keyset pagination in ascending (created_at, id) order: a page starts after
+	// the cursor row, so the first page passes -infinity. NULL filters are ignored.
+	ListArticlesAscending(ctx context.Context, arg ListArticlesAscendingParams) ([]Article, error)
+	// Keyset pagination in descending (created_at, id) order: a page starts after
+	// the cursor row, so the first page passes infinity. NULL filters are ignored.
+	ListArticlesDescending(ctx context.Context, arg ListArticlesDescendingParams) ([]Article, error)
+	ListUserRoles(ctx context.Context, userID uuid.UUID) ([]string, error)
+	// Keyset pagination in ascending (created_at, id) order: a page starts after
+	// the cursor row, so the first page passes -infinity. NULL filters are ignored.
+	ListUsersAscending(ctx context.Context, arg ListUsersAscendingParams) ([]User, error)
+	// Keyset pagination in descending (created_at, id) order: a page starts after
+	// the cursor row, so the first page passes infinity. NULL filters are ignored.
+	ListUsersDescending(ctx context.Context, arg ListUsersDescendingParams) ([]User, error)
+	// Only succeeds for a live token, so two concurrent rotations cannot both win.
+	MarkRefreshTokenRotated(ctx context.Context, id uuid.UUID) (int64, error)
+	RemoveUserRole(ctx context.Context, arg RemoveUserRoleParams) error
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) error
+	RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeToken(ctx context.Context, arg RevokeTokenParams) error
+	// Throttled to one write per key per minute so hot keys do not write on every request.
+	TouchAPIKeyLastUsed(ctx context.Context, id uuid.UUID) error
+	// Only the author can update, checked in the same statement as the write.
+	// NULL arguments keep the current column value so callers can apply partial updates.
 	UpdateArticle(ctx context.Context, arg UpdateArticleParams) (Article, error)
+	// NULL arguments keep the current column value so callers can apply partial updates.
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (int64, error)
+	UpdateUserPlan(ctx context.Context, arg UpdateUserPlanParams) (User, error)
 }
 
 var _ Querier = (*Queries)(nil)
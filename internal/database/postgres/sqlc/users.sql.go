@@ -9,21 +9,23 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (username, email)
-VALUES ($1,$2)
-RETURNING id, username, email, created_at, updated_at
+INSERT INTO users (username, email, password_hash)
+VALUES ($1,$2,$3)
+RETURNING id, username, email, created_at, updated_at, password_hash, plan
 `
 
 type CreateUserParams struct {
-	Username string `db:"username" json:"username"`
-	Email    string `db:"email" json:"email"`
+	Username     string `db:"username" json:"username"`
+	Email        string `db:"email" json:"email"`
+	PasswordHash string `db:"password_hash" json:"-"`
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRow(ctx, createUser, arg.Username, arg.Email)
+	row := q.db.QueryRow(ctx, createUser, arg.Username, arg.Email, arg.PasswordHash)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -31,6 +33,8 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PasswordHash,
+		&i.Plan,
 	)
 	return i, err
 }
@@ -45,7 +49,7 @@ func (q *Queries) DeleteUser(ctx context.Context, id uuid.UUID) error {
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, username, email, created_at, updated_at FROM users WHERE email = $1 LIMIT 1
+SELECT id, username, email, created_at, updated_at, password_hash, plan FROM users WHERE email = $1 LIMIT 1
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
@@ -57,12 +61,14 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PasswordHash,
+		&i.Plan,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, username, email, created_at, updated_at FROM users WHERE id = $1 LIMIT 1
+SELECT id, username, email, created_at, updated_at, password_hash, plan FROM users WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
@@ -74,16 +80,45 @@ func (q *Queries) GetUserByID(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Email,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PasswordHash,
+		&i.Plan,
 	)
 	return i, err
 }
 
-const listUsers = `-- name: ListUsers :many
-SELECT id, username, email, created_at, updated_at FROM users ORDER BY created_at DESC
+const listUsersAscending = `-- name: ListUsersAscending :many
+SELECT id, username, email, created_at, updated_at, password_hash, plan FROM users
+WHERE (created_at, id) > ($1::timestamptz, $2::uuid)
+  AND ($3::text IS NULL OR starts_with(username, $3::text))
+  AND ($4::text IS NULL OR lower(split_part(email, '@', 2)) = lower($4::text))
+  AND ($5::timestamptz IS NULL OR created_at > $5::timestamptz)
+  AND ($6::timestamptz IS NULL OR created_at < $6::timestamptz)
+ORDER BY created_at, id
+LIMIT $7
 `
 
-func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
-	rows, err := q.db.Query(ctx, listUsers)
+type ListUsersAscendingParams struct {
+	CursorCreatedAt pgtype.Timestamptz `db:"cursor_created_at" json:"cursor_created_at"`
+	CursorID        pgtype.UUID        `db:"cursor_id" json:"cursor_id"`
+	UsernamePrefix  pgtype.Text        `db:"username_prefix" json:"username_prefix"`
+	EmailDomain     pgtype.Text        `db:"email_domain" json:"email_domain"`
+	CreatedAfter    pgtype.Timestamptz `db:"created_after" json:"created_after"`
+	CreatedBefore   pgtype.Timestamptz `db:"created_before" json:"created_before"`
+	PageLimit       int32              `db:"page_limit" json:"page_limit"`
+}
+
+// Keyset pagination in ascending (created_at, id) order: a page starts after
+// the cursor row, so the first page passes -infinity. NULL filters are ignored.
+func (q *Queries) ListUsersAscending(ctx context.Context, arg ListUsersAscendingParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, listUsersAscending,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.UsernamePrefix,
+		arg.EmailDomain,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -97,6 +132,67 @@ func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
 			&i.Email,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.PasswordHash,
+			&i.Plan,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersDescending = `-- name: ListUsersDescending :many
+SELECT id, username, email, created_at, updated_at, password_hash, plan FROM users
+WHERE (created_at, id) < ($1::timestamptz, $2::uuid)
+  AND ($3::text IS NULL OR starts_with(username, $3::text))
+  AND ($4::text IS NULL OR lower(split_part(email, '@', 2)) = lower($4::text))
+  AND ($5::timestamptz IS NULL OR created_at > $5::timestamptz)
+  AND ($6::timestamptz IS NULL OR created_at < $6::timestamptz)
+ORDER BY created_at DESC, id DESC
+LIMIT $7
+`
+
+type ListUsersDescendingParams struct {
+	CursorCreatedAt pgtype.Timestamptz `db:"cursor_created_at" json:"cursor_created_at"`
+	CursorID        pgtype.UUID        `db:"cursor_id" json:"cursor_id"`
+	UsernamePrefix  pgtype.Text        `db:"username_prefix" json:"username_prefix"`
+	EmailDomain     pgtype.Text        `db:"email_domain" json:"email_domain"`
+	CreatedAfter    pgtype.Timestamptz `db:"created_after" json:"created_after"`
+	CreatedBefore   pgtype.Timestamptz `db:"created_before" json:"created_before"`
+	PageLimit       int32              `db:"page_limit" json:"page_limit"`
+}
+
+// Keyset pagination in descending (created_at, id) order: a page starts after
+// the cursor row, so the first page passes infinity. NULL filters are ignored.
+func (q *Queries) ListUsersDescending(ctx context.Context, arg ListUsersDescendingParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, listUsersDescending,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.UsernamePrefix,
+		arg.EmailDomain,
+		arg.CreatedAfter,
+		arg.CreatedBefore,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Email,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.PasswordHash,
+			&i.Plan,
 		); err != nil {
 			return nil, err
 		}
@@ -109,17 +205,71 @@ func (q *Queries) ListUsers(ctx context.Context) ([]User, error) {
 }
 
 const updateUser = `-- name: UpdateUser :one
-UPDATE users SET username = $2, email = $3, updated_at = NOW() WHERE id = $1 RETURNING id, username, email, created_at, updated_at
+UPDATE users SET
+    username = COALESCE($1, username),
+    email = COALESCE($2, email),
+    updated_at = NOW()
+WHERE id = $3
+RETURNING id, username, email, created_at, updated_at, password_hash, plan
 `
 
 type UpdateUserParams struct {
-	ID       uuid.UUID `db:"id" json:"id"`
-	Username string    `db:"username" json:"username"`
-	Email    string    `db:"email" json:"email"`
+	Username pgtype.Text `db:"username" json:"username"`
+	Email    pgtype.Text `db:"email" json:"email"`
+	ID       uuid.UUID   `db:"id" json:"id"`
 }
 
+// NULL arguments keep the current column value so callers can apply partial updates.
 func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
-	row := q.db.QueryRow(ctx, updateUser, arg.ID, arg.Username, arg.Email)
+	row := q.db.QueryRow(ctx, updateUser, arg.Username, arg.Email, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.PasswordHash,
+		&i.Plan,
+	)
+	return i, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :execrows
+UPDATE users SET
+    password_hash = $2,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateUserPasswordParams struct {
+	ID           uuid.UUID `db:"id" json:"id"`
+	PasswordHash string    `db:"password_hash" json:"password_hash"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateUserPassword, arg.ID, arg.PasswordHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateUserPlan = `-- name: UpdateUserPlan :one
+UPDATE users SET
+    plan = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, username, email, created_at, updated_at, password_hash, plan
+`
+
+type UpdateUserPlanParams struct {
+	ID   uuid.UUID `db:"id" json:"id"`
+	Plan string    `db:"plan" json:"plan"`
+}
+
+func (q *Queries) UpdateUserPlan(ctx context.Context, arg UpdateUserPlanParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserPlan, arg.ID, arg.Plan)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -127,6 +277,8 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PasswordHash,
+		&i.Plan,
 	)
 	return i, err
 }
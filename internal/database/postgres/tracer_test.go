@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/akshaysangma/go-serve/internal/api-gateway/handlers"
+	"github.com/akshaysangma/go-serve/internal/api-gateway/middleware"
+	"github.com/akshaysangma/go-serve/internal/api-gateway/repositories"
+	"github.com/akshaysangma/go-serve/internal/api-gateway/services"
+	"github.com/akshaysangma/go-serve/internal/common/config"
+	"github.com/akshaysangma/go-serve/internal/common/tracing"
+	db "github.com/akshaysangma/go-serve/internal/database/postgres/sqlc"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// tracedDB stands in for a pgx connection: it runs queryTracer around each
+// query as pgx does and answers that no row was found.
+type tracedDB struct {
+	tracer *queryTracer
+}
+
+func (d tracedDB) Exec(ctx context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	d.trace(ctx, sql)
+	return pgconn.CommandTag{}, nil
+}
+
+func (d tracedDB) Query(ctx context.Context, sql string, _ ...any) (pgx.Rows, error) {
+	d.trace(ctx, sql)
+	return nil, pgx.ErrNoRows
+}
+
+func (d tracedDB) QueryRow(ctx context.Context, sql string, _ ...any) pgx.Row {
+	d.trace(ctx, sql)
+	return noRow{}
+}
+
+func (d tracedDB) trace(ctx context.Context, sql string) {
+	ctx = d.tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: sql})
+	d.tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: pgx.ErrNoRows})
+}
+
+type noRow struct{}
+
+func (noRow) Scan(...any) error { return pgx.ErrNoRows }
+
+// TestQuerySpansJoinRequestTrace serves a request carrying a traceparent
+// header through the tracing middleware, a handler, a service and a sqlc
+// query, and checks that every span belongs to the caller's trace and
+// descends from the server span.
+func TestQuerySpansJoinRequestTrace(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := tracing.NewTracerProvider(sdktrace.NewSimpleSpanProcessor(exporter), config.TracingConfig{ServiceName: "go-serve", SampleRatio: 1})
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	logger := zap.NewNop()
+	articleService := services.NewArticleService(repositories.NewArticleRepository(db.New(tracedDB{tracer: newQueryTracer()})), logger)
+	router := http.NewServeMux()
+	router.Handle("GET /articles/{id}", middleware.ChainMiddleware(
+		middleware.TracingMiddleware(),
+		middleware.RequestLoggerMiddleware(logger),
+	)(handlers.GetArticleByIDHandler(articleService, logger)))
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	const parentSpanID = "00f067aa0ba902b7"
+	req := httptest.NewRequest(http.MethodGet, "/articles/"+uuid.NewString(), nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentSpanID+"-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	byID := make(map[trace.SpanID]tracetest.SpanStub, len(spans))
+	byName := make(map[string]tracetest.SpanStub, len(spans))
+	for _, span := range spans {
+		if got := span.SpanContext.TraceID().String(); got != traceID {
+			t.Errorf("span %q has trace ID %s, want %s", span.Name, got, traceID)
+		}
+		byID[span.SpanContext.SpanID()] = span
+		byName[span.Name] = span
+	}
+
+	server, ok := byName["GET /articles/{id}"]
+	if !ok {
+		t.Fatalf("no server span among %d spans", len(spans))
+	}
+	if server.SpanKind != trace.SpanKindServer {
+		t.Errorf("server span kind = %s, want server", server.SpanKind)
+	}
+	if got := server.Parent.SpanID().String(); got != parentSpanID || !server.Parent.IsRemote() {
+		t.Errorf("server span parent = %s, want remote span %s", got, parentSpanID)
+	}
+
+	for _, name := range []string{"RequestLoggerMiddleware", "GetArticleByIDHandler", "ArticleService.GetArticleByID", "GetArticleByID"} {
+		span, ok := byName[name]
+		if !ok {
+			t.Errorf("no %q span", name)
+			continue
+		}
+		for span.SpanContext.SpanID() != server.SpanContext.SpanID() {
+			parent, ok := byID[span.Parent.SpanID()]
+			if !ok {
+				t.Errorf("span %q does not descend from the server span", name)
+				break
+			}
+			span = parent
+		}
+	}
+	if query := byName["GetArticleByID"]; query.Parent.SpanID() != byName["ArticleService.GetArticleByID"].SpanContext.SpanID() {
+		t.Error("query span is not a child of the service span")
+	}
+}
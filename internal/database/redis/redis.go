@@ -0,0 +1,32 @@
+// Package redis creates the Redis client shared by components that keep
+// state across replicas.
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NewClient creates a client for redisURL, such as redis://localhost:6379/0.
+// The client is returned even when the server cannot be reached, together
+// with the ping error, since go-redis reconnects on later commands.
+func NewClient(redisURL string) (*redis.Client, error) {
+	options, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
+	}
+
+	client := redis.NewClient(options)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		return client, fmt.Errorf("failed to ping Redis: %w", err)
+	}
+
+	return client, nil
+}